@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/quen2404/polluter"
+)
+
+// dryRun prints commands instead
+// of executing them.
+type dryRun struct {
+	polluter.DbEngine
+	w io.Writer
+}
+
+func (d dryRun) Exec(cmds polluter.Commands) error {
+	for _, c := range cmds {
+		args := make([]string, 0, len(c.Args))
+		for _, a := range c.Args {
+			args = append(args, formatArg(a))
+		}
+		if _, err := fmt.Fprintf(d.w, "%s %s\n", c.Q, strings.Join(args, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d dryRun) Truncate(_ context.Context, names ...string) error {
+	_, err := fmt.Fprintf(d.w, "truncate %s\n", strings.Join(names, ", "))
+	return err
+}
+
+func formatArg(a interface{}) string {
+	if b, ok := a.([]byte); ok {
+		return string(b)
+	}
+	data, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Sprint(a)
+	}
+	return string(data)
+}
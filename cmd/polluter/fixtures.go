@@ -0,0 +1,95 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/quen2404/polluter/parser"
+	"github.com/quen2404/polluter/parser/csv"
+	"github.com/quen2404/polluter/parser/json"
+	"github.com/quen2404/polluter/parser/toml"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/romanyx/jwalk"
+)
+
+// load parses the fixtures of paths and merges
+// them. Files of a directory are taken in
+// lexical order, those of unknown formats
+// are skipped.
+func load(paths []string) (jwalk.ObjectWalker, error) {
+	files := make([]string, 0, len(paths))
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+
+		entries, err := ioutil.ReadDir(p)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, 0, len(entries))
+		for _, e := range entries {
+			if !e.IsDir() && parserFor(e.Name()) != nil {
+				names = append(names, e.Name())
+			}
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			files = append(files, filepath.Join(p, n))
+		}
+	}
+
+	objs := make([]jwalk.ObjectWalker, 0, len(files))
+	for _, f := range files {
+		obj, err := parseFile(f)
+		if err != nil {
+			return nil, errors.Wrap(err, f)
+		}
+		objs = append(objs, obj)
+	}
+
+	return parser.Merge(objs...)
+}
+
+func parseFile(name string) (jwalk.ObjectWalker, error) {
+	p := parserFor(name)
+	if p == nil {
+		return nil, errors.New("unknown fixture format")
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return p.Parse(f)
+}
+
+// parserFor picks the parser of a fixture file
+// from its extension. CSV files hold the table
+// named after them.
+func parserFor(name string) parser.Parser {
+	ext := strings.ToLower(filepath.Ext(name))
+	switch ext {
+	case ".yml", ".yaml":
+		return yaml.YAMLParser()
+	case ".json":
+		return json.JSONParser()
+	case ".toml":
+		return toml.TOMLParser()
+	case ".csv":
+		return csv.CSVParser(strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)))
+	default:
+		return nil
+	}
+}
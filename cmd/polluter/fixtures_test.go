@@ -0,0 +1,41 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_load(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"01_roles.yml":  "roles:\n- id: 1\n  name: User\n",
+		"02_users.json": `{"users":[{"id":1,"name":"Roman"}]}`,
+		"comments.csv":  "id,body\n1,Hello\n",
+		"README.md":     "ignored",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	extra := filepath.Join(t.TempDir(), "more.toml")
+	if err := ioutil.WriteFile(extra, []byte("[[users]]\nid = 2\nname = \"Dmitry\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	obj, err := load([]string{dir, extra})
+	if err != nil {
+		assert.Nil(t, err)
+		return
+	}
+
+	data, err := obj.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"roles":[{"id":1,"name":"User"}],"users":[{"id":1,"name":"Roman"},{"id":2,"name":"Dmitry"}],"comments":[{"id":"1","body":"Hello"}]}`, string(data))
+
+	_, err = load([]string{filepath.Join(dir, "README.md")})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,116 @@
+// Command polluter seeds a database with fixtures:
+//
+//	polluter [flags] DSN FIXTURE...
+//
+// The engine is picked from the scheme of the DSN:
+// postgres://, mysql://, mongodb:// or redis://.
+// Fixtures are YAML, JSON, TOML or CSV files, or
+// directories of them, merged in the given order.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	_ "github.com/lib/pq"
+	"github.com/quen2404/polluter"
+	_ "github.com/quen2404/polluter/database/mongo"
+	_ "github.com/quen2404/polluter/database/mysql"
+	_ "github.com/quen2404/polluter/database/postgres"
+	_ "github.com/quen2404/polluter/database/redis"
+	"github.com/quen2404/polluter/parser/json"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("polluter", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var (
+		dryRun   = flags.Bool("dry-run", false, "print the commands instead of executing them")
+		generate = flags.Bool("generate", false, "evaluate generator expressions such as $uuid() in values")
+		truncate = flags.Bool("truncate", false, "empty the tables, collections and keys of the fixtures first")
+		upsert   = flags.Bool("upsert", false, "update existing rows instead of failing")
+		verify   = flags.Bool("verify", false, "check the database holds the fixtures instead of seeding them")
+	)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: polluter [flags] DSN FIXTURE...")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return 2
+	}
+
+	if err := pollute(context.Background(), flags.Arg(0), flags.Args()[1:], config{
+		dryRun:   *dryRun,
+		generate: *generate,
+		truncate: *truncate,
+		upsert:   *upsert,
+		verify:   *verify,
+	}, stdout); err != nil {
+		fmt.Fprintf(stderr, "polluter: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+type config struct {
+	dryRun   bool
+	generate bool
+	truncate bool
+	upsert   bool
+	verify   bool
+}
+
+func pollute(ctx context.Context, dsn string, paths []string, cfg config, stdout io.Writer) error {
+	obj, err := load(paths)
+	if err != nil {
+		return err
+	}
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	var openOpts []polluter.OpenOption
+	if cfg.upsert {
+		openOpts = append(openOpts, polluter.WithUpsert())
+	}
+	engine, conn, err := polluter.Open(dsn, openOpts...)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if cfg.verify {
+		p := polluter.New(engine, json.JSONParser())
+		return p.Verify(ctx, bytes.NewReader(data))
+	}
+	if cfg.dryRun {
+		engine = dryRun{DbEngine: engine, w: stdout}
+	}
+
+	var opts []polluter.Option
+	if cfg.generate {
+		opts = append(opts, polluter.Generators())
+	}
+	if cfg.truncate {
+		opts = append(opts, polluter.Truncate())
+	}
+	// Fixtures are merged into a single JSON
+	// document, so that they are seeded at once.
+	p := polluter.New(engine, json.JSONParser(), opts...)
+
+	return p.Pollute(bytes.NewReader(data))
+}
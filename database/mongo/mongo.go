@@ -8,17 +8,61 @@ import (
 	"github.com/romanyx/jwalk"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type mongoEngine struct {
-	db *mongo.Database
-}
+type (
+	mongoEngine struct {
+		db        *mongo.Database
+		upsert    bool
+		normalize bool
+		log       *polluter.CommandLogger
+	}
+
+	// Option configures the Mongo engine.
+	Option func(*mongoEngine)
+)
 
 func (m mongoEngine) Exec(cmds polluter.Commands) error {
+	return m.ExecContext(context.Background(), cmds)
+}
+
+func (m mongoEngine) ExecContext(ctx context.Context, cmds polluter.Commands) error {
+	ctx = m.log.Context(ctx)
 	for _, c := range cmds {
-		coll := m.db.Collection(c.Q)
-		if _, err := coll.InsertMany(context.Background(), c.Args); err != nil {
-			return errors.Wrap(err, "failed to insert one")
+		if err := polluter.RunCommand(ctx, c, func(c polluter.Command) error {
+			return m.insert(ctx, c)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m mongoEngine) insert(ctx context.Context, c polluter.Command) error {
+	coll := m.db.Collection(c.Q)
+	if m.upsert {
+		return errors.Wrap(replace(ctx, coll, c.Args), "failed to replace")
+	}
+	_, err := coll.InsertMany(ctx, c.Args)
+	return errors.Wrap(err, "failed to insert one")
+}
+
+// replace replaces the documents with the
+// same _id, documents without one are inserted.
+func replace(ctx context.Context, coll *mongo.Collection, docs []interface{}) error {
+	for _, d := range docs {
+		doc, _ := d.(bson.D)
+		id, ok := doc.Map()["_id"]
+		if !ok {
+			if _, err := coll.InsertOne(ctx, doc); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, err := coll.ReplaceOne(ctx, bson.D{{Key: "_id", Value: id}}, doc, options.Replace().SetUpsert(true)); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -37,9 +81,12 @@ func (m mongoEngine) Build(obj jwalk.ObjectWalker) (polluter.Commands, error) {
 		}
 		args := make([]interface{}, len(docs))
 		for i, doc := range docs {
+			if m.normalize {
+				doc = omitDefaults(doc)
+			}
 			args[i] = doc
 		}
-		cmds = append(cmds, polluter.Command{Q: collection, Args: args})
+		cmds = append(cmds, polluter.Command{Q: collection, Args: args, Table: collection, Rows: len(args)})
 		return nil
 	}); err != nil {
 		return nil, err
@@ -48,8 +95,182 @@ func (m mongoEngine) Build(obj jwalk.ObjectWalker) (polluter.Commands, error) {
 	return cmds, nil
 }
 
+// omitDefaults removes the fields of doc
+// given the default value.
+func omitDefaults(doc bson.D) bson.D {
+	res := make(bson.D, 0, len(doc))
+	for _, e := range doc {
+		if v, ok := e.Value.(bson.D); ok && len(v) == 1 && v[0].Key == polluter.DefaultKey && v[0].Value == true {
+			continue
+		}
+		res = append(res, e)
+	}
+	return res
+}
+
+// Revert deletes the documents of obj
+// by _id, or by all their fields. It
+// fails with Upsert, which may have
+// replaced documents that existed before.
+func (m mongoEngine) Revert(ctx context.Context, obj jwalk.ObjectWalker) error {
+	if m.upsert {
+		return errors.New("revert is not supported with upsert")
+	}
+
+	cmds, err := m.Build(obj)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range cmds {
+		coll := m.db.Collection(c.Q)
+		for _, d := range c.Args {
+			filter, _ := d.(bson.D)
+			if id, ok := filter.Map()["_id"]; ok {
+				filter = bson.D{{Key: "_id", Value: id}}
+			}
+			if _, err := coll.DeleteOne(ctx, filter); err != nil {
+				return errors.Wrapf(err, "delete from %s", c.Q)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Truncate deletes all the documents
+// of the collections.
+func (m mongoEngine) Truncate(ctx context.Context, collections ...string) error {
+	for _, c := range collections {
+		if _, err := m.db.Collection(c).DeleteMany(ctx, bson.D{}); err != nil {
+			return errors.Wrapf(err, "delete %s", c)
+		}
+	}
+	return nil
+}
+
+// Fetch reads back the documents
+// of the collections of obj.
+func (m mongoEngine) Fetch(ctx context.Context, obj jwalk.ObjectWalker) (polluter.Record, error) {
+	doc := make(polluter.Record, 0)
+
+	if err := obj.Walk(func(collection string, _ interface{}) error {
+		cur, err := m.db.Collection(collection).Find(ctx, bson.D{})
+		if err != nil {
+			return errors.Wrapf(err, "find %s", collection)
+		}
+		defer cur.Close(ctx)
+
+		records, err := readRecords(ctx, cur)
+		if err != nil {
+			return errors.Wrapf(err, "find %s", collection)
+		}
+
+		doc = append(doc, polluter.Field{Name: collection, Value: records})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Dump reads the selected collections.
+// Where is an extended JSON filter.
+func (m mongoEngine) Dump(ctx context.Context, sel ...polluter.Selection) (polluter.Record, error) {
+	doc := make(polluter.Record, 0, len(sel))
+
+	for _, s := range sel {
+		filter := bson.D{}
+		if s.Where != "" {
+			if err := bson.UnmarshalExtJSON([]byte(s.Where), false, &filter); err != nil {
+				return nil, errors.Wrapf(err, "filter of %s", s.Name)
+			}
+		}
+
+		opts := options.Find()
+		if s.Limit > 0 {
+			opts.SetLimit(int64(s.Limit))
+		}
+
+		cur, err := m.db.Collection(s.Name).Find(ctx, filter, opts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "find %s", s.Name)
+		}
+
+		records, err := readRecords(ctx, cur)
+		cur.Close(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "find %s", s.Name)
+		}
+
+		doc = append(doc, polluter.Field{Name: s.Name, Value: records})
+	}
+
+	return doc, nil
+}
+
+// readRecords reads documents as relaxed
+// extended JSON, the format of fixtures.
+func readRecords(ctx context.Context, cur *mongo.Cursor) ([]polluter.Record, error) {
+	records := make([]polluter.Record, 0)
+	for cur.Next(ctx) {
+		data, err := bson.MarshalExtJSON(cur.Current, false, false)
+		if err != nil {
+			return nil, err
+		}
+
+		i, err := jwalk.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		w, ok := i.(jwalk.ObjectWalker)
+		if !ok {
+			return nil, errors.New("unexpected document")
+		}
+
+		r, err := polluter.RecordOf(w)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+
+	return records, cur.Err()
+}
+
 // MongoEngine option enables
 // Mongo engine for Polluter.
-func MongoEngine(cli *mongo.Database) polluter.DbEngine {
-	return mongoEngine{cli}
+func MongoEngine(cli *mongo.Database, opts ...Option) polluter.DbEngine {
+	m := mongoEngine{db: cli}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	return m
+}
+
+// Upsert option replaces the documents
+// with the same _id instead of failing.
+func Upsert() Option {
+	return func(m *mongoEngine) {
+		m.upsert = true
+	}
+}
+
+// Normalize option leaves out the fields of
+// documents given the default value, so that
+// they are missing like the fields other
+// documents of the collection omit.
+func Normalize() Option {
+	return func(m *mongoEngine) {
+		m.normalize = true
+	}
+}
+
+// Log option logs the commands executed
+// by the engine, see polluter.Log.
+func Log(l polluter.Logger, opts ...polluter.LogOption) Option {
+	return func(m *mongoEngine) {
+		m.log = polluter.NewCommandLogger(l, opts...)
+	}
 }
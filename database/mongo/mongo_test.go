@@ -47,6 +47,7 @@ func Test_mongoEngineBuild(t *testing.T) {
 	tests := []struct {
 		name   string
 		input  []byte
+		opts   []mongo.Option
 		expect polluter.Commands
 	}{
 		{
@@ -54,7 +55,9 @@ func Test_mongoEngineBuild(t *testing.T) {
 			input: []byte(`{"users":[{"id":1,"name":"Roman"},{"id":2,"name":"Dmitry"}],"roles":[{"id":2,"role_ids":[1,2]}]}`),
 			expect: polluter.Commands{
 				{
-					Q: "users",
+					Q:     "users",
+					Table: "users",
+					Rows:  2,
 					Args: []interface{}{
 						bson.D{
 							bson.E{
@@ -79,7 +82,9 @@ func Test_mongoEngineBuild(t *testing.T) {
 					},
 				},
 				{
-					Q: "roles",
+					Q:     "roles",
+					Table: "roles",
+					Rows:  1,
 					Args: []interface{}{
 						bson.D{
 							bson.E{
@@ -95,6 +100,36 @@ func Test_mongoEngineBuild(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "normalize",
+			input: []byte(`{"users":[{"id":1,"name":{"$default":true}},{"id":2,"name":"Dmitry"}]}`),
+			opts:  []mongo.Option{mongo.Normalize()},
+			expect: polluter.Commands{
+				{
+					Q:     "users",
+					Table: "users",
+					Rows:  2,
+					Args: []interface{}{
+						bson.D{
+							bson.E{
+								Key:   "id",
+								Value: int32(1),
+							},
+						},
+						bson.D{
+							bson.E{
+								Key:   "id",
+								Value: int32(2),
+							},
+							bson.E{
+								Key:   "name",
+								Value: "Dmitry",
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -107,7 +142,7 @@ func Test_mongoEngineBuild(t *testing.T) {
 				assert.Nil(t, err)
 			}
 
-			e := mongo.MongoEngine(nil)
+			e := mongo.MongoEngine(nil, tt.opts...)
 			got, err := e.Build(obj)
 			assert.Nil(t, err)
 			assert.Equal(t, tt.expect, got)
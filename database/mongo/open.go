@@ -0,0 +1,51 @@
+package mongo
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/quen2404/polluter"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	polluter.Register("mongodb", open)
+	polluter.Register("mongodb+srv", open)
+}
+
+// open connects to the database named
+// by the path of mongodb:// DSNs.
+func open(dsn string, cfg polluter.OpenConfig) (polluter.DbEngine, io.Closer, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parse dsn")
+	}
+	name := strings.TrimPrefix(u.Path, "/")
+	if name == "" {
+		return nil, nil, errors.New("mongo dsn has no database")
+	}
+
+	cli, err := mongo.Connect(context.Background(), options.Client().ApplyURI(dsn))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "connect mongo")
+	}
+
+	var opts []Option
+	if cfg.Upsert {
+		opts = append(opts, Upsert())
+	}
+
+	return MongoEngine(cli.Database(name), opts...), client{cli}, nil
+}
+
+type client struct {
+	*mongo.Client
+}
+
+func (c client) Close() error {
+	return c.Disconnect(context.Background())
+}
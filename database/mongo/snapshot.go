@@ -0,0 +1,113 @@
+package mongo
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// batchSize is the number of documents
+// copied by a single insert.
+const batchSize = 1000
+
+// snapshot returns the database a snapshot
+// is saved in: database_name.
+func (m mongoEngine) snapshot(name string) *mongo.Database {
+	return m.db.Client().Database(m.db.Name() + "_" + name)
+}
+
+// Snapshot copies the documents of the
+// collections to the snapshot database.
+// Empty collections are created there
+// too, so that the snapshot exists.
+func (m mongoEngine) Snapshot(ctx context.Context, name string) error {
+	snapshot := m.snapshot(name)
+	if err := snapshot.Drop(ctx); err != nil {
+		return errors.Wrap(err, "drop snapshot")
+	}
+
+	collections, err := m.db.ListCollectionNames(ctx, bson.D{})
+	if err != nil {
+		return errors.Wrap(err, "list collections")
+	}
+	for _, c := range collections {
+		if err := snapshot.CreateCollection(ctx, c); err != nil {
+			return errors.Wrapf(err, "create %s", c)
+		}
+	}
+	return copyCollections(ctx, m.db, snapshot)
+}
+
+// Restore empties the collections and
+// copies back the snapshot documents.
+// Indexes are left as they are.
+func (m mongoEngine) Restore(ctx context.Context, name string) error {
+	saved, err := m.snapshot(name).ListCollectionNames(ctx, bson.D{})
+	if err != nil {
+		return errors.Wrap(err, "list snapshot collections")
+	}
+	if len(saved) == 0 {
+		return errors.Errorf("no snapshot %s", name)
+	}
+
+	collections, err := m.db.ListCollectionNames(ctx, bson.D{})
+	if err != nil {
+		return errors.Wrap(err, "list collections")
+	}
+	if err := m.Truncate(ctx, collections...); err != nil {
+		return err
+	}
+	return copyCollections(ctx, m.snapshot(name), m.db)
+}
+
+// DropSnapshot drops the snapshot database.
+func (m mongoEngine) DropSnapshot(ctx context.Context, name string) error {
+	return errors.Wrap(m.snapshot(name).Drop(ctx), "drop snapshot")
+}
+
+func copyCollections(ctx context.Context, from, to *mongo.Database) error {
+	collections, err := from.ListCollectionNames(ctx, bson.D{})
+	if err != nil {
+		return errors.Wrap(err, "list collections")
+	}
+
+	for _, c := range collections {
+		if err := copyCollection(ctx, from.Collection(c), to.Collection(c)); err != nil {
+			return errors.Wrapf(err, "copy %s", c)
+		}
+	}
+	return nil
+}
+
+func copyCollection(ctx context.Context, from, to *mongo.Collection) error {
+	cur, err := from.Find(ctx, bson.D{})
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	docs := make([]interface{}, 0, batchSize)
+	flush := func() error {
+		if len(docs) == 0 {
+			return nil
+		}
+		_, err := to.InsertMany(ctx, docs)
+		docs = docs[:0]
+		return err
+	}
+
+	for cur.Next(ctx) {
+		docs = append(docs, append(bson.Raw(nil), cur.Current...))
+		if len(docs) == batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,63 @@
+package mongo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/database/mongo"
+	"github.com/quen2404/polluter/internal/db_test"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func Test_mongoEngine_snapshot(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	ctx := context.Background()
+	db, teardown := db_test.PrepareMongoDB(t)
+	defer func() {
+		_ = teardown()
+	}()
+
+	e := mongo.MongoEngine(db).(polluter.Snapshotter)
+	users := db.Collection("users")
+
+	_, err := users.InsertOne(ctx, bson.D{{Key: "name", Value: "Roman"}})
+	assert.Nil(t, err)
+	assert.Nil(t, e.Snapshot(ctx, "base"))
+	defer e.DropSnapshot(ctx, "base")
+
+	_, err = users.InsertOne(ctx, bson.D{{Key: "name", Value: "Dmitry"}})
+	assert.Nil(t, err)
+	assert.Nil(t, e.Restore(ctx, "base"))
+
+	count, err := users.CountDocuments(ctx, bson.D{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
+
+func Test_mongoEngine_restoreMissing(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	ctx := context.Background()
+	db, teardown := db_test.PrepareMongoDB(t)
+	defer func() {
+		_ = teardown()
+	}()
+
+	e := mongo.MongoEngine(db).(polluter.Snapshotter)
+	users := db.Collection("users")
+
+	_, err := users.InsertOne(ctx, bson.D{{Key: "name", Value: "Roman"}})
+	assert.Nil(t, err)
+	assert.NotNil(t, e.Restore(ctx, "missing"))
+
+	count, err := users.CountDocuments(ctx, bson.D{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,282 @@
+package multi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/quen2404/polluter"
+	"github.com/romanyx/jwalk"
+)
+
+type (
+	multiEngine struct {
+		engines  map[string]polluter.DbEngine
+		routes   map[string]string
+		fallback string
+	}
+
+	// Option configures the multi engine.
+	Option func(*multiEngine)
+
+	// batch is what an engine built for
+	// its part of the fixture.
+	batch struct {
+		cmds polluter.Commands
+		obj  jwalk.ObjectWalker
+	}
+
+	// part is the fixture of an engine.
+	part struct {
+		engine string
+		doc    polluter.Record
+		// keys maps the keys given to the
+		// engine to those of the fixture.
+		keys map[string]string
+	}
+)
+
+func (m multiEngine) Exec(cmds polluter.Commands) error {
+	return m.ExecContext(context.Background(), cmds)
+}
+
+// ExecContext executes the commands of each
+// engine in turn. When an engine fails, the
+// engines which succeeded are reverted if
+// they implement polluter.Reverter.
+func (m multiEngine) ExecContext(ctx context.Context, cmds polluter.Commands) error {
+	for i, c := range cmds {
+		b, ok := batchOf(c)
+		if !ok {
+			return errors.Errorf("unexpected command for %s", c.Q)
+		}
+
+		if err := execEngine(ctx, m.engines[c.Q], b.cmds); err != nil {
+			err = errors.Wrap(err, c.Q)
+			if rErr := m.revert(ctx, cmds[:i]); rErr != nil {
+				err = errors.Wrap(rErr, err.Error())
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func buildEngine(ctx context.Context, e polluter.DbEngine, obj jwalk.ObjectWalker) (polluter.Commands, error) {
+	if cb, ok := e.(polluter.ContextBuilder); ok {
+		return cb.BuildContext(ctx, obj)
+	}
+	return e.Build(obj)
+}
+
+func execEngine(ctx context.Context, e polluter.DbEngine, cmds polluter.Commands) error {
+	if ce, ok := e.(polluter.ContextExecer); ok {
+		return ce.ExecContext(ctx, cmds)
+	}
+	return e.Exec(cmds)
+}
+
+// revert undoes the commands
+// in the reverse order.
+func (m multiEngine) revert(ctx context.Context, cmds polluter.Commands) error {
+	failed := make([]string, 0)
+	for i := len(cmds) - 1; i >= 0; i-- {
+		b, _ := batchOf(cmds[i])
+		r, ok := m.engines[cmds[i].Q].(polluter.Reverter)
+		if !ok {
+			failed = append(failed, cmds[i].Q+" does not support revert")
+			continue
+		}
+		if err := r.Revert(ctx, b.obj); err != nil {
+			failed = append(failed, cmds[i].Q+": "+err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return errors.Errorf("revert failed: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func batchOf(c polluter.Command) (batch, bool) {
+	if len(c.Args) != 1 {
+		return batch{}, false
+	}
+	b, ok := c.Args[0].(batch)
+	return b, ok
+}
+
+func (m multiEngine) Build(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+	return m.BuildContext(context.Background(), obj)
+}
+
+// BuildContext builds the commands of each engine
+// for its part of the fixture. There is a command
+// per engine, in the order engines first appear
+// in the fixture, named after the engine.
+func (m multiEngine) BuildContext(ctx context.Context, obj jwalk.ObjectWalker) (polluter.Commands, error) {
+	parts, err := m.split(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make(polluter.Commands, 0, len(parts))
+	for _, p := range parts {
+		sub, err := p.doc.Walker()
+		if err != nil {
+			return nil, errors.Wrap(err, p.engine)
+		}
+
+		c, err := buildEngine(ctx, m.engines[p.engine], sub)
+		if err != nil {
+			return nil, errors.Wrap(err, p.engine)
+		}
+
+		cmds = append(cmds, polluter.Command{Q: p.engine, Args: []interface{}{batch{cmds: c, obj: sub}}})
+	}
+
+	return cmds, nil
+}
+
+// split splits the fixture by engine.
+func (m multiEngine) split(obj jwalk.ObjectWalker) ([]*part, error) {
+	doc, err := polluter.RecordOf(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "read records")
+	}
+
+	parts := make([]*part, 0)
+	byEngine := make(map[string]*part)
+	for _, f := range doc {
+		engine, key, err := m.route(f.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		p, ok := byEngine[engine]
+		if !ok {
+			p = &part{engine: engine, keys: make(map[string]string)}
+			byEngine[engine] = p
+			parts = append(parts, p)
+		}
+		p.doc = append(p.doc, polluter.Field{Name: key, Value: f.Value})
+		p.keys[key] = f.Name
+	}
+
+	return parts, nil
+}
+
+// route returns the engine of a fixture key and
+// the key given to it: keys prefixed with the
+// name of an engine and a dot go to that engine,
+// then routes apply, then the default engine.
+func (m multiEngine) route(key string) (string, string, error) {
+	if i := strings.Index(key, "."); i > 0 {
+		if _, ok := m.engines[key[:i]]; ok {
+			return key[:i], key[i+1:], nil
+		}
+	}
+
+	engine, ok := m.routes[key]
+	if !ok {
+		engine = m.fallback
+	}
+	if _, ok := m.engines[engine]; !ok {
+		return "", "", errors.Errorf("no engine for %s", key)
+	}
+	return engine, key, nil
+}
+
+// Fetch reads back each part of the fixture
+// from its engine, which must implement
+// polluter.Checker.
+func (m multiEngine) Fetch(ctx context.Context, obj jwalk.ObjectWalker) (polluter.Record, error) {
+	parts, err := m.split(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := make(polluter.Record, 0)
+	for _, p := range parts {
+		c, ok := m.engines[p.engine].(polluter.Checker)
+		if !ok {
+			return nil, errors.Errorf("%s does not support verify", p.engine)
+		}
+
+		sub, err := p.doc.Walker()
+		if err != nil {
+			return nil, errors.Wrap(err, p.engine)
+		}
+
+		actual, err := c.Fetch(ctx, sub)
+		if err != nil {
+			return nil, errors.Wrap(err, p.engine)
+		}
+		for _, f := range actual {
+			doc = append(doc, polluter.Field{Name: p.keys[f.Name], Value: f.Value})
+		}
+	}
+
+	return doc, nil
+}
+
+// Truncate routes the names to their engines,
+// which must implement polluter.Truncater.
+func (m multiEngine) Truncate(ctx context.Context, names ...string) error {
+	engines := make([]string, 0)
+	byEngine := make(map[string][]string)
+	for _, n := range names {
+		engine, key, err := m.route(n)
+		if err != nil {
+			return err
+		}
+		if _, ok := byEngine[engine]; !ok {
+			engines = append(engines, engine)
+		}
+		byEngine[engine] = append(byEngine[engine], key)
+	}
+
+	for _, e := range engines {
+		t, ok := m.engines[e].(polluter.Truncater)
+		if !ok {
+			return errors.Errorf("%s does not support truncate", e)
+		}
+		if err := t.Truncate(ctx, byEngine[e]...); err != nil {
+			return errors.Wrap(err, e)
+		}
+	}
+
+	return nil
+}
+
+// MultiEngine option enables an engine which
+// seeds several databases from one fixture.
+// Top level keys are prefixed with the name
+// of their engine, e.g. postgres.users or
+// redis.session:1, or routed with options.
+func MultiEngine(engines map[string]polluter.DbEngine, opts ...Option) polluter.DbEngine {
+	m := multiEngine{
+		engines: engines,
+		routes:  make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(&m)
+	}
+	return m
+}
+
+// Route option sends the top level
+// key to the named engine.
+func Route(key, engine string) Option {
+	return func(m *multiEngine) {
+		m.routes[key] = engine
+	}
+}
+
+// Default option sends keys which are
+// not routed to the named engine.
+func Default(engine string) Option {
+	return func(m *multiEngine) {
+		m.fallback = engine
+	}
+}
@@ -0,0 +1,131 @@
+package multi_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/database/multi"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/romanyx/jwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEngine builds a command per key
+// and records what it executes.
+type fakeEngine struct {
+	name     string
+	log      *[]string
+	fail     bool
+	state    polluter.Record
+	reverted *[]string
+}
+
+func (e fakeEngine) Build(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+	cmds := make(polluter.Commands, 0)
+	err := obj.Walk(func(key string, _ interface{}) error {
+		cmds = append(cmds, polluter.Command{Q: key})
+		return nil
+	})
+	return cmds, err
+}
+
+func (e fakeEngine) Exec(cmds polluter.Commands) error {
+	if e.fail {
+		return errors.New("failed")
+	}
+	for _, c := range cmds {
+		*e.log = append(*e.log, e.name+":"+c.Q)
+	}
+	return nil
+}
+
+func (e fakeEngine) Revert(_ context.Context, obj jwalk.ObjectWalker) error {
+	return obj.Walk(func(key string, _ interface{}) error {
+		*e.reverted = append(*e.reverted, e.name+":"+key)
+		return nil
+	})
+}
+
+func (e fakeEngine) Fetch(context.Context, jwalk.ObjectWalker) (polluter.Record, error) {
+	return e.state, nil
+}
+
+const input = `postgres.users:
+- id: 1
+  name: Roman
+redis.session:1: abc
+roles:
+- id: 1
+settings: {}
+`
+
+func TestMultiEngine(t *testing.T) {
+	tests := []struct {
+		name     string
+		fail     bool
+		opts     []multi.Option
+		expect   []string
+		reverted []string
+		wantErr  bool
+	}{
+		{
+			name:   "prefixes, routes and default",
+			opts:   []multi.Option{multi.Route("roles", "postgres"), multi.Default("redis")},
+			expect: []string{"postgres:users", "postgres:roles", "redis:session:1", "redis:settings"},
+		},
+		{
+			name:    "key without engine",
+			opts:    []multi.Option{multi.Route("roles", "postgres")},
+			wantErr: true,
+		},
+		{
+			name:     "revert on failure",
+			fail:     true,
+			opts:     []multi.Option{multi.Route("roles", "postgres"), multi.Default("redis")},
+			expect:   []string{"postgres:users", "postgres:roles"},
+			reverted: []string{"postgres:users", "postgres:roles"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var log, reverted []string
+			e := multi.MultiEngine(map[string]polluter.DbEngine{
+				"postgres": fakeEngine{name: "postgres", log: &log, reverted: &reverted},
+				"redis":    fakeEngine{name: "redis", log: &log, reverted: &reverted, fail: tt.fail},
+			}, tt.opts...)
+
+			err := polluter.New(e, yaml.YAMLParser()).Pollute(strings.NewReader(input))
+			if tt.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+			assert.Equal(t, tt.expect, log)
+			assert.Equal(t, tt.reverted, reverted)
+		})
+	}
+}
+
+func TestMultiEngine_Verify(t *testing.T) {
+	var log []string
+	e := multi.MultiEngine(map[string]polluter.DbEngine{
+		"postgres": fakeEngine{log: &log, state: polluter.Record{
+			{Name: "users", Value: []polluter.Record{{{Name: "id", Value: 1}}}},
+		}},
+		"redis": fakeEngine{log: &log, state: polluter.Record{
+			{Name: "session:1", Value: "abc"},
+		}},
+	})
+
+	p := polluter.New(e, yaml.YAMLParser())
+	assert.Nil(t, p.Verify(context.Background(), strings.NewReader("postgres.users:\n- id: 1\nredis.session:1: abc\n")))
+	assert.NotNil(t, p.Verify(context.Background(), strings.NewReader("redis.session:1: def\n")))
+}
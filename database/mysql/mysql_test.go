@@ -49,6 +49,7 @@ func Test_mysqlEngine_build(t *testing.T) {
 	tests := []struct {
 		name   string
 		input  []byte
+		opts   []mysql.Option
 		expect polluter.Commands
 	}{
 		{
@@ -56,21 +57,27 @@ func Test_mysqlEngine_build(t *testing.T) {
 			input: []byte(`{"users":[{"id":1,"name":"Roman"},{"id":2,"name":"Dmitry"}],"roles":[{"id":2,"role_ids":[1,2]}]}`),
 			expect: polluter.Commands{
 				{
-					Q: "INSERT INTO `users` (`id`, `name`) VALUES (?, ?);",
+					Table: "users",
+					Rows:  1,
+					Q:     "INSERT INTO `users` (`id`, `name`) VALUES (?, ?);",
 					Args: []interface{}{
 						float64(1),
 						"Roman",
 					},
 				},
 				{
-					Q: "INSERT INTO `users` (`id`, `name`) VALUES (?, ?);",
+					Table: "users",
+					Rows:  1,
+					Q:     "INSERT INTO `users` (`id`, `name`) VALUES (?, ?);",
 					Args: []interface{}{
 						float64(2),
 						"Dmitry",
 					},
 				},
 				{
-					Q: "INSERT INTO `roles` (`id`, `role_ids`) VALUES (?, ?);",
+					Table: "roles",
+					Rows:  1,
+					Q:     "INSERT INTO `roles` (`id`, `role_ids`) VALUES (?, ?);",
 					Args: []interface{}{
 						float64(2),
 						[]interface{}{
@@ -81,6 +88,30 @@ func Test_mysqlEngine_build(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "upsert",
+			input: []byte(`{"users":[{"id":1,"name":"Roman"}],"roles":[{"id":2}]}`),
+			opts:  []mysql.Option{mysql.Upsert()},
+			expect: polluter.Commands{
+				{
+					Table: "users",
+					Rows:  1,
+					Q:     "INSERT INTO `users` (`id`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`);",
+					Args: []interface{}{
+						float64(1),
+						"Roman",
+					},
+				},
+				{
+					Table: "roles",
+					Rows:  1,
+					Q:     "INSERT INTO `roles` (`id`) VALUES (?) ON DUPLICATE KEY UPDATE `id` = VALUES(`id`);",
+					Args: []interface{}{
+						float64(2),
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -93,7 +124,7 @@ func Test_mysqlEngine_build(t *testing.T) {
 				assert.Nil(t, err)
 			}
 
-			e := mysql.MySQLEngine(nil)
+			e := mysql.MySQLEngine(nil, tt.opts...)
 			got, err := e.Build(obj)
 			assert.Nil(t, err)
 			assert.Equal(t, tt.expect, got)
@@ -115,7 +146,9 @@ func Test_mysqlEngine_exec(t *testing.T) {
 			name: "valid query",
 			args: polluter.Commands{
 				{
-					Q: "INSERT INTO `users` (`id`, `name`) VALUES (?, ?);",
+					Table: "users",
+					Rows:  1,
+					Q:     "INSERT INTO `users` (`id`, `name`) VALUES (?, ?);",
 					Args: []interface{}{
 						1,
 						"Roman",
@@ -127,7 +160,9 @@ func Test_mysqlEngine_exec(t *testing.T) {
 			name: "invalid query",
 			args: polluter.Commands{
 				{
-					Q: "INSERT INTO `roles` (`id`, `name`) VALUES (?, ?);",
+					Table: "roles",
+					Rows:  1,
+					Q:     "INSERT INTO `roles` (`id`, `name`) VALUES (?, ?);",
 					Args: []interface{}{
 						1,
 						"User",
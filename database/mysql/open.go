@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"database/sql"
+	"io"
+	"net/url"
+	"strings"
+
+	driver "github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+	"github.com/quen2404/polluter"
+)
+
+func init() {
+	polluter.Register("mysql", open)
+}
+
+func open(dsn string, cfg polluter.OpenConfig) (polluter.DbEngine, io.Closer, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parse dsn")
+	}
+
+	db, err := sql.Open("mysql", driverDSN(u))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "open mysql")
+	}
+
+	var opts []Option
+	if cfg.Upsert {
+		opts = append(opts, Upsert())
+	}
+
+	return MySQLEngine(db, opts...), db, nil
+}
+
+// driverDSN converts a mysql:// URL to
+// the DSN format of the MySQL driver.
+func driverDSN(u *url.URL) string {
+	cfg := driver.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = u.Host
+	if u.Port() == "" {
+		cfg.Addr = u.Host + ":3306"
+	}
+	cfg.DBName = strings.TrimPrefix(u.Path, "/")
+	if u.User != nil {
+		cfg.User = u.User.Username()
+		cfg.Passwd, _ = u.User.Password()
+	}
+
+	query := u.Query()
+	if len(query) > 0 {
+		cfg.Params = make(map[string]string, len(query))
+		for k := range query {
+			cfg.Params[k] = query.Get(k)
+		}
+	}
+
+	return cfg.FormatDSN()
+}
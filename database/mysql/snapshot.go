@@ -0,0 +1,147 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/database/sqldb"
+)
+
+type snapshotter struct {
+	db *sql.DB
+}
+
+// Snapshots returns what saves and restores the
+// current database by copying its tables to a
+// schema named after it and the snapshot:
+// database_name. Restoring copies the rows back.
+// Tables created after the snapshot are left
+// alone.
+func Snapshots(db *sql.DB) polluter.Snapshotter {
+	return snapshotter{db: db}
+}
+
+// Snapshot copies the tables of
+// the database to the snapshot.
+func (s snapshotter) Snapshot(ctx context.Context, name string) error {
+	return s.run(ctx, name, func(conn *sql.Conn, database, snapshot string) ([]string, []string, error) {
+		tables, err := baseTables(ctx, conn, database)
+		if err != nil {
+			return nil, nil, err
+		}
+		return snapshotStatements(database, snapshot, tables), nil, nil
+	})
+}
+
+// Restore replaces the rows of the tables
+// of the database with the snapshot ones.
+func (s snapshotter) Restore(ctx context.Context, name string) error {
+	return s.run(ctx, name, func(conn *sql.Conn, database, snapshot string) ([]string, []string, error) {
+		tables, err := baseTables(ctx, conn, snapshot)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(tables) == 0 {
+			return nil, nil, errors.Errorf("no snapshot %s", name)
+		}
+		stmts, teardown := restoreStatements(database, snapshot, tables)
+		return stmts, teardown, nil
+	})
+}
+
+// DropSnapshot drops the snapshot schema.
+func (s snapshotter) DropSnapshot(ctx context.Context, name string) error {
+	return s.run(ctx, name, func(_ *sql.Conn, _, snapshot string) ([]string, []string, error) {
+		return []string{fmt.Sprintf("DROP DATABASE IF EXISTS %s;", sqldb.MySQL.Quote(snapshot))}, nil, nil
+	})
+}
+
+// run runs the statements of fn on a single
+// connection, then its teardown in any case,
+// as it restores settings of the connection
+// before it goes back to the pool.
+func (s snapshotter) run(ctx context.Context, name string, fn func(conn *sql.Conn, database, snapshot string) (stmts, teardown []string, err error)) (err error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "conn")
+	}
+	defer conn.Close()
+
+	var database string
+	if err := conn.QueryRowContext(ctx, "SELECT DATABASE();").Scan(&database); err != nil {
+		return errors.Wrap(err, "current database")
+	}
+
+	stmts, teardown, err := fn(conn, database, database+"_"+name)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, stmt := range teardown {
+			if _, tErr := conn.ExecContext(context.Background(), stmt); tErr != nil && err == nil {
+				err = errors.Wrap(tErr, stmt)
+			}
+		}
+	}()
+
+	for _, stmt := range stmts {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return errors.Wrap(err, stmt)
+		}
+	}
+	return nil
+}
+
+func baseTables(ctx context.Context, conn *sql.Conn, schema string) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT TABLE_NAME
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'
+		ORDER BY TABLE_NAME;`, schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "tables")
+	}
+	defer rows.Close()
+
+	tables := make([]string, 0)
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, errors.Wrap(err, "tables")
+		}
+		tables = append(tables, t)
+	}
+	return tables, errors.Wrap(rows.Err(), "tables")
+}
+
+func snapshotStatements(database, snapshot string, tables []string) []string {
+	q := sqldb.MySQL.Quote
+	stmts := []string{
+		fmt.Sprintf("DROP DATABASE IF EXISTS %s;", q(snapshot)),
+		fmt.Sprintf("CREATE DATABASE %s;", q(snapshot)),
+	}
+	for _, t := range tables {
+		stmts = append(stmts,
+			fmt.Sprintf("CREATE TABLE %s.%s LIKE %s.%s;", q(snapshot), q(t), q(database), q(t)),
+			fmt.Sprintf("INSERT INTO %s.%s SELECT * FROM %s.%s;", q(snapshot), q(t), q(database), q(t)),
+		)
+	}
+	return stmts
+}
+
+// restoreStatements returns the statements
+// restoring the tables, and the teardown
+// enabling foreign key checks again.
+func restoreStatements(database, snapshot string, tables []string) (stmts, teardown []string) {
+	q := sqldb.MySQL.Quote
+	stmts = []string{"SET FOREIGN_KEY_CHECKS = 0;"}
+	for _, t := range tables {
+		stmts = append(stmts,
+			fmt.Sprintf("TRUNCATE TABLE %s.%s;", q(database), q(t)),
+			fmt.Sprintf("INSERT INTO %s.%s SELECT * FROM %s.%s;", q(database), q(t), q(snapshot), q(t)),
+		)
+	}
+	return stmts, []string{"SET FOREIGN_KEY_CHECKS = 1;"}
+}
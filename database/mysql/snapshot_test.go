@@ -0,0 +1,26 @@
+package mysql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_snapshotStatements(t *testing.T) {
+	assert.Equal(t, []string{
+		"DROP DATABASE IF EXISTS `test_base`;",
+		"CREATE DATABASE `test_base`;",
+		"CREATE TABLE `test_base`.`users` LIKE `test`.`users`;",
+		"INSERT INTO `test_base`.`users` SELECT * FROM `test`.`users`;",
+	}, snapshotStatements("test", "test_base", []string{"users"}))
+}
+
+func Test_restoreStatements(t *testing.T) {
+	stmts, teardown := restoreStatements("test", "test_base", []string{"users"})
+	assert.Equal(t, []string{
+		"SET FOREIGN_KEY_CHECKS = 0;",
+		"TRUNCATE TABLE `test`.`users`;",
+		"INSERT INTO `test`.`users` SELECT * FROM `test_base`.`users`;",
+	}, stmts)
+	assert.Equal(t, []string{"SET FOREIGN_KEY_CHECKS = 1;"}, teardown)
+}
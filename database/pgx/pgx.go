@@ -0,0 +1,545 @@
+package pgx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	pgxv4 "github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/database/sqldb"
+	"github.com/romanyx/jwalk"
+)
+
+type (
+	// DB is a *pgxpool.Pool, a *pgx.Conn
+	// or anything which begins transactions.
+	DB interface {
+		Begin(ctx context.Context) (pgxv4.Tx, error)
+	}
+
+	// Copy is the argument of the commands
+	// which load a table with CopyFrom.
+	Copy struct {
+		Table   string
+		Columns []string
+		Rows    [][]interface{}
+	}
+
+	pgxEngine struct {
+		db        DB
+		copy      bool
+		normalize bool
+		upsert    []string
+		log       *polluter.CommandLogger
+	}
+
+	// Option configures the pgx engine.
+	Option func(*pgxEngine)
+)
+
+func (e pgxEngine) Exec(cmds polluter.Commands) error {
+	return e.ExecContext(context.Background(), cmds)
+}
+
+// ExecContext executes the commands in a
+// transaction. Inserts are pipelined in
+// batches, sent before each copy and at
+// the end: BeforeExec hooks are called
+// when they are queued, logs, spans and
+// AfterExec hooks when their results are
+// read.
+func (e pgxEngine) ExecContext(ctx context.Context, cmds polluter.Commands) (err error) {
+	ctx = e.log.Context(ctx)
+	tx, err := e.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "tx begin")
+	}
+	defer func() {
+		if err == nil {
+			err = errors.Wrap(tx.Commit(ctx), "commit")
+			return
+		}
+		if rErr := tx.Rollback(ctx); rErr != nil {
+			err = errors.Wrap(rErr, err.Error())
+		}
+	}()
+
+	b := new(batch)
+	for _, c := range cmds {
+		if _, ok := copyOf(c); !ok {
+			if err := b.queue(ctx, c); err != nil {
+				return errors.Wrap(err, "exec")
+			}
+			continue
+		}
+
+		if err := b.send(ctx, tx); err != nil {
+			return errors.Wrap(err, "exec")
+		}
+		if err := polluter.RunCommand(ctx, c, func(c polluter.Command) error {
+			cp, ok := copyOf(c)
+			if !ok {
+				return errors.Errorf("unexpected command %s", c.Q)
+			}
+			_, err := tx.CopyFrom(ctx, pgxv4.Identifier{cp.Table}, cp.Columns, pgxv4.CopyFromRows(cp.Rows))
+			return errors.Wrapf(err, "copy %s", cp.Table)
+		}); err != nil {
+			return errors.Wrap(err, "exec")
+		}
+	}
+
+	return errors.Wrap(b.send(ctx, tx), "exec")
+}
+
+// batch holds queued commands.
+type batch struct {
+	b    pgxv4.Batch
+	cmds polluter.Commands
+}
+
+// queue prepares c and queues it
+// unless an exec hook skips it.
+func (b *batch) queue(ctx context.Context, c polluter.Command) error {
+	c, err := polluter.PrepareCommand(ctx, c)
+	if errors.Is(err, polluter.ErrSkip) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	b.b.Queue(c.Q, c.Args...)
+	b.cmds = append(b.cmds, c)
+	return nil
+}
+
+func (b *batch) send(ctx context.Context, tx pgxv4.Tx) error {
+	if len(b.cmds) == 0 {
+		return nil
+	}
+	cmds := b.cmds
+	br := tx.SendBatch(ctx, &b.b)
+	b.b, b.cmds = pgxv4.Batch{}, nil
+
+	for _, c := range cmds {
+		if err := polluter.RunPrepared(ctx, c, func(polluter.Command) error {
+			_, err := br.Exec()
+			return err
+		}); err != nil {
+			br.Close()
+			return errors.Wrap(err, c.Q)
+		}
+	}
+	return br.Close()
+}
+
+func copyOf(c polluter.Command) (Copy, bool) {
+	if len(c.Args) != 1 {
+		return Copy{}, false
+	}
+	cp, ok := c.Args[0].(Copy)
+	return cp, ok
+}
+
+// Build builds an INSERT per row of each table,
+// or copies with the CopyFrom option. Raw SQL
+// values are written in the statement. Other
+// nested objects and arrays of objects are
+// passed as records, encoded as JSON for json
+// and jsonb columns.
+func (e pgxEngine) Build(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+	doc, err := polluter.RecordOf(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "read records")
+	}
+
+	cmds := make(polluter.Commands, 0)
+	for _, f := range doc {
+		rows, ok := f.Value.([]polluter.Record)
+		if !ok {
+			continue
+		}
+
+		if e.normalize {
+			c, err := e.normalized(f.Name, rows)
+			if err != nil {
+				return nil, errors.Wrap(err, f.Name)
+			}
+			cmds = append(cmds, c...)
+			continue
+		}
+
+		// last is the index of the copy
+		// consecutive rows are added to.
+		last := -1
+		for _, row := range rows {
+			if values, ok := e.copyValues(row, names(row)); ok {
+				if last < 0 || !sameColumns(cmds[last].Args[0].(Copy).Columns, row) {
+					cmds = append(cmds, copyCommand(f.Name, names(row)))
+					last = len(cmds) - 1
+				}
+				cp := cmds[last].Args[0].(Copy)
+				cp.Rows = append(cp.Rows, values)
+				cmds[last].Args[0] = cp
+				cmds[last].Rows = len(cp.Rows)
+				continue
+			}
+			last = -1
+
+			c, err := e.insert(f.Name, names(row), []polluter.Record{row})
+			if err != nil {
+				return nil, errors.Wrap(err, f.Name)
+			}
+			cmds = append(cmds, c)
+		}
+	}
+
+	return cmds, nil
+}
+
+// maxArgs bounds the placeholders of an
+// INSERT, under the limit of Postgres.
+const maxArgs = 65535
+
+// normalized builds the commands of the rows of
+// a table with the same columns: a copy when
+// all of them can be copied, multi-row INSERTs
+// otherwise, as copies cannot fill columns
+// with DEFAULT.
+func (e pgxEngine) normalized(table string, rows []polluter.Record) (polluter.Commands, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	columns := union(rows)
+
+	if values, ok := e.copyRows(rows, columns); ok {
+		cp := copyCommand(table, columns)
+		cp.Args[0] = Copy{Table: table, Columns: columns, Rows: values}
+		cp.Rows = len(values)
+		return polluter.Commands{cp}, nil
+	}
+
+	cmds := make(polluter.Commands, 0)
+	for _, batch := range e.batches(rows, len(columns)) {
+		c, err := e.insert(table, columns, batch)
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, c)
+	}
+	return cmds, nil
+}
+
+// copyRows returns the values of rows
+// to copy if all of them can be.
+func (e pgxEngine) copyRows(rows []polluter.Record, columns []string) ([][]interface{}, bool) {
+	values := make([][]interface{}, 0, len(rows))
+	for _, row := range rows {
+		v, ok := e.copyValues(row, columns)
+		if !ok {
+			return nil, false
+		}
+		values = append(values, v)
+	}
+	return values, true
+}
+
+// batches splits rows into multi-row INSERTs
+// of width columns. Rows which give different
+// columns are upserted apart, so that the
+// columns filled with DEFAULT are not updated.
+func (e pgxEngine) batches(rows []polluter.Record, width int) [][]polluter.Record {
+	size := len(rows)
+	if width > 0 && maxArgs/width < size {
+		size = maxArgs / width
+	}
+	if size < 1 {
+		size = 1
+	}
+
+	batches := make([][]polluter.Record, 0)
+	start := 0
+	for i := 1; i <= len(rows); i++ {
+		if i < len(rows) && i-start < size &&
+			(len(e.upsert) == 0 || sameSet(names(rows[start]), names(rows[i]))) {
+			continue
+		}
+		batches = append(batches, rows[start:i])
+		start = i
+	}
+	return batches
+}
+
+func copyCommand(table string, columns []string) polluter.Command {
+	return polluter.Command{
+		Q:     fmt.Sprintf("COPY %s (%s) FROM STDIN;", sqldb.Postgres.Quote(table), quoteAll(columns)),
+		Args:  []interface{}{Copy{Table: table, Columns: columns}},
+		Table: table,
+	}
+}
+
+// insert builds the INSERT of rows with the
+// given columns, filling the ones a row omits
+// with DEFAULT. Upserts update the columns
+// given by the first row.
+func (e pgxEngine) insert(table string, columns []string, rows []polluter.Record) (polluter.Command, error) {
+	ins := sqldb.Insert{
+		Table:   table,
+		Columns: columns,
+		Upsert:  len(e.upsert) > 0,
+		Keys:    e.upsert,
+	}
+	if ins.Upsert && len(rows) > 0 {
+		ins.Update = names(rows[0])
+	}
+
+	args := make([]interface{}, 0)
+	for _, row := range rows {
+		values := make([]string, 0, len(columns))
+		for _, name := range columns {
+			v, ok := row.Get(name)
+			if !ok {
+				values = append(values, "DEFAULT")
+				continue
+			}
+			if expr, ok := polluter.RawSQL(v); ok {
+				values = append(values, expr)
+				continue
+			}
+			args = append(args, value(v))
+			values = append(values, sqldb.Postgres.Placeholder(len(args)))
+		}
+		ins.Values = append(ins.Values, values)
+	}
+
+	q, err := sqldb.Postgres.Insert(ins)
+	if err != nil {
+		return polluter.Command{}, err
+	}
+
+	return polluter.Command{Q: q, Args: args, Table: table, Rows: len(rows)}, nil
+}
+
+// copyValues returns the values of the columns
+// of a row to copy, when enabled, unless it has
+// raw SQL values, misses columns or rows are
+// upserted.
+func (e pgxEngine) copyValues(row polluter.Record, columns []string) ([]interface{}, bool) {
+	if !e.copy || len(e.upsert) > 0 {
+		return nil, false
+	}
+
+	values := make([]interface{}, len(columns))
+	for i, name := range columns {
+		v, ok := row.Get(name)
+		if !ok {
+			return nil, false
+		}
+		if _, ok := polluter.RawSQL(v); ok {
+			return nil, false
+		}
+		values[i] = value(v)
+	}
+	return values, true
+}
+
+func names(row polluter.Record) []string {
+	names := make([]string, len(row))
+	for i, f := range row {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// sameSet reports whether a
+// and b hold the same names.
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]bool, len(a))
+	for _, n := range a {
+		seen[n] = true
+	}
+	for _, n := range b {
+		if !seen[n] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameColumns(columns []string, row polluter.Record) bool {
+	if len(columns) != len(row) {
+		return false
+	}
+	for i, f := range row {
+		if f.Name != columns[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// union returns the columns of rows
+// in the order they first appear.
+func union(rows []polluter.Record) []string {
+	columns := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		for _, f := range row {
+			if !seen[f.Name] {
+				seen[f.Name] = true
+				columns = append(columns, f.Name)
+			}
+		}
+	}
+	return columns
+}
+
+// value converts integers to int64 and keeps
+// other numbers as text, so that they are not
+// rounded, like strings which pgx sends as text
+// for UUID, dates, intervals and other types.
+func value(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		return v.String()
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = value(item)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+func quoteAll(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = sqldb.Postgres.Quote(n)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// Truncate empties the tables and resets
+// their sequences in a transaction.
+func (e pgxEngine) Truncate(ctx context.Context, tables ...string) error {
+	if len(tables) == 0 {
+		return nil
+	}
+
+	tx, err := e.db.Begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "tx begin")
+	}
+	// Postgres truncates without setup or teardown.
+	_, stmts, _ := sqldb.Postgres.Truncate(tables)
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(ctx, stmt); err != nil {
+			if rErr := tx.Rollback(ctx); rErr != nil {
+				err = errors.Wrap(rErr, err.Error())
+			}
+			return errors.Wrap(err, "truncate")
+		}
+	}
+
+	return errors.Wrap(tx.Commit(ctx), "commit")
+}
+
+// Describe reads the columns of the
+// tables of the current schema.
+func (e pgxEngine) Describe(ctx context.Context) (map[string][]polluter.Column, error) {
+	tx, err := e.db.Begin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "tx begin")
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, sqldb.Postgres.Columns())
+	if err != nil {
+		return nil, errors.Wrap(err, "columns")
+	}
+	defer rows.Close()
+
+	tables := make(map[string][]polluter.Column)
+	for rows.Next() {
+		var (
+			table, typ string
+			c          polluter.Column
+		)
+		if err := rows.Scan(&table, &c.Name, &typ, &c.Required); err != nil {
+			return nil, errors.Wrap(err, "columns")
+		}
+		tables[table] = append(tables[table], c)
+	}
+
+	return tables, errors.Wrap(rows.Err(), "columns")
+}
+
+// PgxEngine option enables Postgres engine
+// for Polluter on top of pgx, given a pool
+// or a connection.
+func PgxEngine(db DB, opts ...Option) polluter.DbEngine {
+	e := pgxEngine{db: db}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return e
+}
+
+// CopyFrom option loads the rows of each table
+// with the COPY protocol, a copy per run of
+// rows with the same columns, in the same
+// order. Rows with raw SQL values are
+// inserted. Values are
+// sent in binary and must suit the types of
+// the columns: numbers, strings for text and
+// UUID, times, records for JSON.
+func CopyFrom() Option {
+	return func(e *pgxEngine) {
+		e.copy = true
+	}
+}
+
+// Upsert option updates the rows which conflict
+// on the key columns, id by default, instead of
+// failing. Upserted rows are not copied.
+func Upsert(keys ...string) Option {
+	if len(keys) == 0 {
+		keys = []string{"id"}
+	}
+	return func(e *pgxEngine) {
+		e.upsert = keys
+	}
+}
+
+// Normalize option inserts all the rows of a
+// table with the same columns, in the order
+// they first appear, filling the ones a row
+// omits with DEFAULT. Tables are then copied
+// in one go with CopyFrom when all their rows
+// give all the columns, and inserted by
+// multi-row INSERTs otherwise. Upserts do not
+// update the columns filled with DEFAULT.
+func Normalize() Option {
+	return func(e *pgxEngine) {
+		e.normalize = true
+	}
+}
+
+// Log option logs the commands executed
+// by the engine, see polluter.Log.
+func Log(l polluter.Logger, opts ...polluter.LogOption) Option {
+	return func(e *pgxEngine) {
+		e.log = polluter.NewCommandLogger(l, opts...)
+	}
+}
@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"database/sql"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/quen2404/polluter"
+)
+
+func init() {
+	polluter.Register("postgres", open)
+	polluter.Register("postgresql", open)
+}
+
+// open opens postgres:// DSNs with the postgres
+// database/sql driver, which must be registered,
+// e.g. by importing github.com/lib/pq.
+func open(dsn string, cfg polluter.OpenConfig) (polluter.DbEngine, io.Closer, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "open postgres")
+	}
+
+	var opts []Option
+	if cfg.Upsert {
+		opts = append(opts, Upsert())
+	}
+
+	return PostgresEngine(db, opts...), db, nil
+}
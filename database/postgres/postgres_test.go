@@ -2,6 +2,7 @@ package postgres_test
 
 import (
 	"bytes"
+	"context"
 	"flag"
 	"fmt"
 	"github.com/quen2404/polluter/database/postgres"
@@ -50,6 +51,7 @@ func Test_postgresEngine_build(t *testing.T) {
 	tests := []struct {
 		name   string
 		input  []byte
+		opts   []postgres.Option
 		expect polluter.Commands
 	}{
 		{
@@ -57,21 +59,27 @@ func Test_postgresEngine_build(t *testing.T) {
 			input: []byte(`{"users":[{"id":1,"name":"Roman"},{"id":2,"name":"Dmitry"}],"roles":[{"id":2,"role_ids":[1,2]}]}`),
 			expect: polluter.Commands{
 				{
-					Q: `INSERT INTO "users" ("id", "name") VALUES ($1, $2);`,
+					Table: "users",
+					Rows:  1,
+					Q:     `INSERT INTO "users" ("id", "name") VALUES ($1, $2);`,
 					Args: []interface{}{
 						float64(1),
 						"Roman",
 					},
 				},
 				{
-					Q: `INSERT INTO "users" ("id", "name") VALUES ($1, $2);`,
+					Table: "users",
+					Rows:  1,
+					Q:     `INSERT INTO "users" ("id", "name") VALUES ($1, $2);`,
 					Args: []interface{}{
 						float64(2),
 						"Dmitry",
 					},
 				},
 				{
-					Q: `INSERT INTO "roles" ("id", "role_ids") VALUES ($1, $2);`,
+					Table: "roles",
+					Rows:  1,
+					Q:     `INSERT INTO "roles" ("id", "role_ids") VALUES ($1, $2);`,
 					Args: []interface{}{
 						float64(2),
 						[]interface{}{
@@ -82,6 +90,30 @@ func Test_postgresEngine_build(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "upsert",
+			input: []byte(`{"users":[{"id":1,"name":"Roman"}],"roles":[{"id":2}]}`),
+			opts:  []postgres.Option{postgres.Upsert()},
+			expect: polluter.Commands{
+				{
+					Table: "users",
+					Rows:  1,
+					Q:     `INSERT INTO "users" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name";`,
+					Args: []interface{}{
+						float64(1),
+						"Roman",
+					},
+				},
+				{
+					Table: "roles",
+					Rows:  1,
+					Q:     `INSERT INTO "roles" ("id") VALUES ($1) ON CONFLICT ("id") DO NOTHING;`,
+					Args: []interface{}{
+						float64(2),
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -94,7 +126,7 @@ func Test_postgresEngine_build(t *testing.T) {
 				assert.Nil(t, err)
 			}
 
-			e := postgres.PostgresEngine(nil)
+			e := postgres.PostgresEngine(nil, tt.opts...)
 			got, err := e.Build(obj)
 			assert.Nil(t, err)
 			assert.Equal(t, tt.expect, got)
@@ -116,7 +148,9 @@ func Test_postgresEngine_exec(t *testing.T) {
 			name: "valid query",
 			args: polluter.Commands{
 				{
-					Q: `INSERT INTO "users" ("id", "name") VALUES ($1, $2);`,
+					Table: "users",
+					Rows:  1,
+					Q:     `INSERT INTO "users" ("id", "name") VALUES ($1, $2);`,
 					Args: []interface{}{
 						1,
 						"Roman",
@@ -128,7 +162,9 @@ func Test_postgresEngine_exec(t *testing.T) {
 			name: "invalid query",
 			args: polluter.Commands{
 				{
-					Q: `INSERT INTO "roles" ("id", "name") VALUES ($1, $2);`,
+					Table: "roles",
+					Rows:  1,
+					Q:     `INSERT INTO "roles" ("id", "name") VALUES ($1, $2);`,
 					Args: []interface{}{
 						1,
 						"User",
@@ -163,3 +199,65 @@ func Test_postgresEngine_exec(t *testing.T) {
 		})
 	}
 }
+
+func Test_postgresEngine_execTx(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	db, teardown := db_test.PreparePostgresDB(t)
+	defer func() {
+		_ = teardown()
+	}()
+
+	tx, err := db.Begin()
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer tx.Rollback()
+
+	e := postgres.PostgresEngine(tx)
+	assert.NotNil(t, e.Exec(polluter.Commands{{Q: `INSERT INTO "roles" ("id") VALUES ($1);`, Args: []interface{}{1}}}))
+	assert.Nil(t, e.Exec(polluter.Commands{{Q: `INSERT INTO "users" ("id", "name") VALUES ($1, $2);`, Args: []interface{}{1, "Roman"}}}))
+
+	var count int
+	assert.Nil(t, tx.QueryRow(`SELECT COUNT(*) FROM "users";`).Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func Test_postgresEngine_dumpBinary(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	ctx := context.Background()
+	db, teardown := db_test.PreparePostgresDB(t)
+	defer func() {
+		_ = teardown()
+	}()
+
+	data := []byte{0, 1, 0xfe, 0xff}
+	_, err := db.Exec(`CREATE TABLE files (id integer NOT NULL, data bytea)`)
+	if !assert.Nil(t, err) {
+		return
+	}
+	_, err = db.Exec(`INSERT INTO files (id, data) VALUES ($1, $2)`, 1, data)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	p := polluter.New(postgres.PostgresEngine(db, postgres.Coerce()), json.JSONParser())
+	buf := new(bytes.Buffer)
+	if !assert.Nil(t, p.Dump(ctx, buf, polluter.Selection{Name: "files"})) {
+		return
+	}
+	assert.JSONEq(t, `{"files":[{"id":1,"data":"AAH+/w=="}]}`, buf.String())
+
+	_, err = db.Exec(`DELETE FROM files`)
+	assert.Nil(t, err)
+	assert.Nil(t, p.Pollute(buf))
+
+	var got []byte
+	assert.Nil(t, db.QueryRow(`SELECT data FROM files`).Scan(&got))
+	assert.Equal(t, data, got)
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/database/sqldb"
+)
+
+type snapshotter struct {
+	admin    sqldb.DB
+	database string
+}
+
+// Snapshots returns what saves and restores the
+// database with template databases, named after
+// it and the snapshot: database_name. admin is
+// connected to another database of the server,
+// like postgres, as a user who may create
+// databases and owns the database. Other
+// connections to the database are terminated
+// while snapshotting and restoring, so pools
+// reconnect afterwards. A restore fails, and
+// leaves the database as it was, if a client
+// reconnects before the database is replaced.
+func Snapshots(admin sqldb.DB, database string) polluter.Snapshotter {
+	return snapshotter{admin: admin, database: database}
+}
+
+func (s snapshotter) name(snapshot string) string {
+	return s.database + "_" + snapshot
+}
+
+// Snapshot creates the snapshot
+// database from the database.
+func (s snapshotter) Snapshot(ctx context.Context, name string) error {
+	return s.copy(ctx, s.database, s.name(name))
+}
+
+// Restore recreates the database
+// from the snapshot database.
+func (s snapshotter) Restore(ctx context.Context, name string) error {
+	return s.copy(ctx, s.name(name), s.database)
+}
+
+// DropSnapshot drops the snapshot database.
+func (s snapshotter) DropSnapshot(ctx context.Context, name string) error {
+	_, err := s.admin.ExecContext(ctx, "DROP DATABASE IF EXISTS "+sqldb.Postgres.Quote(s.name(name))+";")
+	return errors.Wrap(err, "drop database")
+}
+
+// copy replaces the database to with a copy of
+// from, made under a temporary name first, so
+// that to is kept when from does not exist or
+// cannot be copied. from is closed to new
+// connections meanwhile, to be a template.
+func (s snapshotter) copy(ctx context.Context, from, to string) (err error) {
+	q := sqldb.Postgres.Quote
+	tmp := "polluter_tmp_" + to
+
+	if err := s.exec(ctx, "ALTER DATABASE "+q(from)+" WITH ALLOW_CONNECTIONS false;"); err != nil {
+		return errors.Wrapf(err, "close %s", from)
+	}
+	defer func() {
+		// Connections are allowed again even
+		// when ctx is done.
+		if oErr := s.exec(context.Background(), "ALTER DATABASE "+q(from)+" WITH ALLOW_CONNECTIONS true;"); oErr != nil && err == nil {
+			err = errors.Wrapf(oErr, "open %s", from)
+		}
+	}()
+
+	if err := s.terminate(ctx, from); err != nil {
+		return err
+	}
+	if err := s.exec(ctx, "DROP DATABASE IF EXISTS "+q(tmp)+";"); err != nil {
+		return errors.Wrap(err, "drop database")
+	}
+	if err := s.exec(ctx, "CREATE DATABASE "+q(tmp)+" TEMPLATE "+q(from)+";"); err != nil {
+		return errors.Wrap(err, "create database")
+	}
+
+	if err := s.terminate(ctx, to); err != nil {
+		return err
+	}
+	if err := s.exec(ctx, "DROP DATABASE IF EXISTS "+q(to)+";"); err != nil {
+		return errors.Wrap(err, "drop database")
+	}
+	return errors.Wrap(s.exec(ctx, "ALTER DATABASE "+q(tmp)+" RENAME TO "+q(to)+";"), "rename database")
+}
+
+func (s snapshotter) terminate(ctx context.Context, db string) error {
+	_, err := s.admin.ExecContext(ctx, `SELECT pg_terminate_backend(pid)
+			FROM pg_stat_activity
+			WHERE datname = $1 AND pid <> pg_backend_pid();`, db)
+	return errors.Wrapf(err, "terminate connections to %s", db)
+}
+
+func (s snapshotter) exec(ctx context.Context, q string) error {
+	_, err := s.admin.ExecContext(ctx, q)
+	return err
+}
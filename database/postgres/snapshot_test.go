@@ -0,0 +1,104 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter/database/postgres"
+	"github.com/stretchr/testify/assert"
+)
+
+// adminDB records the statements run on
+// the admin connection, and fails those
+// containing fail, if set.
+type adminDB struct {
+	queries []string
+	fail    string
+}
+
+func (db *adminDB) ExecContext(_ context.Context, q string, args ...interface{}) (sql.Result, error) {
+	if len(args) > 0 {
+		q = "terminate " + args[0].(string)
+	}
+	db.queries = append(db.queries, q)
+	if db.fail != "" && strings.Contains(q, db.fail) {
+		return nil, errors.New("failed")
+	}
+	return nil, nil
+}
+
+func (db *adminDB) QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestSnapshots(t *testing.T) {
+	ctx := context.Background()
+	db := new(adminDB)
+	s := postgres.Snapshots(db, "test")
+
+	assert.Nil(t, s.Snapshot(ctx, "base"))
+	assert.Nil(t, s.Restore(ctx, "base"))
+	assert.Nil(t, s.DropSnapshot(ctx, "base"))
+
+	assert.Equal(t, []string{
+		`ALTER DATABASE "test" WITH ALLOW_CONNECTIONS false;`,
+		"terminate test",
+		`DROP DATABASE IF EXISTS "polluter_tmp_test_base";`,
+		`CREATE DATABASE "polluter_tmp_test_base" TEMPLATE "test";`,
+		"terminate test_base",
+		`DROP DATABASE IF EXISTS "test_base";`,
+		`ALTER DATABASE "polluter_tmp_test_base" RENAME TO "test_base";`,
+		`ALTER DATABASE "test" WITH ALLOW_CONNECTIONS true;`,
+		`ALTER DATABASE "test_base" WITH ALLOW_CONNECTIONS false;`,
+		"terminate test_base",
+		`DROP DATABASE IF EXISTS "polluter_tmp_test";`,
+		`CREATE DATABASE "polluter_tmp_test" TEMPLATE "test_base";`,
+		"terminate test",
+		`DROP DATABASE IF EXISTS "test";`,
+		`ALTER DATABASE "polluter_tmp_test" RENAME TO "test";`,
+		`ALTER DATABASE "test_base" WITH ALLOW_CONNECTIONS true;`,
+		`DROP DATABASE IF EXISTS "test_base";`,
+	}, db.queries)
+}
+
+func TestSnapshots_restoreFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		fail   string
+		expect []string
+	}{
+		{
+			name: "missing snapshot",
+			fail: `ALTER DATABASE "test_nope" WITH ALLOW_CONNECTIONS false`,
+			expect: []string{
+				`ALTER DATABASE "test_nope" WITH ALLOW_CONNECTIONS false;`,
+			},
+		},
+		{
+			name: "copy failure",
+			fail: "CREATE DATABASE",
+			expect: []string{
+				`ALTER DATABASE "test_nope" WITH ALLOW_CONNECTIONS false;`,
+				"terminate test_nope",
+				`DROP DATABASE IF EXISTS "polluter_tmp_test";`,
+				`CREATE DATABASE "polluter_tmp_test" TEMPLATE "test_nope";`,
+				`ALTER DATABASE "test_nope" WITH ALLOW_CONNECTIONS true;`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := &adminDB{fail: tt.fail}
+			err := postgres.Snapshots(db, "test").Restore(context.Background(), "nope")
+			assert.NotNil(t, err)
+			assert.Equal(t, tt.expect, db.queries)
+		})
+	}
+}
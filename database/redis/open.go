@@ -0,0 +1,26 @@
+package redis
+
+import (
+	"io"
+
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+	"github.com/quen2404/polluter"
+)
+
+func init() {
+	polluter.Register("redis", open)
+	polluter.Register("rediss", open)
+}
+
+// open connects to redis:// DSNs. Keys are
+// overwritten, upserts are the default.
+func open(dsn string, _ polluter.OpenConfig) (polluter.DbEngine, io.Closer, error) {
+	opt, err := redis.ParseURL(dsn)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parse dsn")
+	}
+
+	cli := redis.NewClient(opt)
+	return RedisEngine(cli), cli, nil
+}
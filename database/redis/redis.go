@@ -1,21 +1,61 @@
 package redis
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"github.com/quen2404/polluter"
+	"sort"
+	"sync"
 
 	"github.com/go-redis/redis"
 	"github.com/pkg/errors"
 	"github.com/romanyx/jwalk"
 )
 
-type redisEngine struct {
-	cli *redis.Client
-}
+type (
+	redisEngine struct {
+		cli       *redis.Client
+		log       *polluter.CommandLogger
+		snapshots *snapshots
+		created   *created
+	}
+
+	// created keeps the keys which did not
+	// exist before the engine set them.
+	created struct {
+		mu   sync.Mutex
+		keys map[string]bool
+	}
+
+	// Option configures the Redis engine.
+	Option func(*redisEngine)
+)
 
 func (e redisEngine) Exec(cmds polluter.Commands) error {
+	return e.ExecContext(context.Background(), cmds)
+}
+
+func (e redisEngine) ExecContext(ctx context.Context, cmds polluter.Commands) error {
+	ctx = e.log.Context(ctx)
+	cli := e.cli.WithContext(ctx)
 	for _, cmd := range cmds {
-		if err := e.cli.Set(cmd.Q, cmd.Args[0], 0).Err(); err != nil {
+		if err := polluter.RunCommand(ctx, cmd, func(cmd polluter.Command) error {
+			ok, err := cli.SetNX(cmd.Q, cmd.Args[0], 0).Result()
+			if err != nil {
+				return err
+			}
+			if !ok {
+				if err := cli.Set(cmd.Q, cmd.Args[0], 0).Err(); err != nil {
+					return err
+				}
+			}
+			if ok {
+				e.created.add(cmd.Q)
+			}
+			e.snapshots.seed(cmd.Q)
+			return nil
+		}); err != nil {
 			return errors.Wrap(err, "failed to set")
 		}
 	}
@@ -31,7 +71,7 @@ func (e redisEngine) Build(obj jwalk.ObjectWalker) (polluter.Commands, error) {
 			return err
 		}
 
-		cmds = append(cmds, polluter.Command{Q: key, Args: []interface{}{data}})
+		cmds = append(cmds, polluter.Command{Q: key, Args: []interface{}{data}, Table: key, Rows: 1})
 		return nil
 	}); err != nil {
 		return nil, err
@@ -40,8 +80,145 @@ func (e redisEngine) Build(obj jwalk.ObjectWalker) (polluter.Commands, error) {
 	return cmds, nil
 }
 
+// Truncate deletes the keys.
+func (e redisEngine) Truncate(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return errors.Wrap(e.cli.WithContext(ctx).Del(keys...).Err(), "failed to delete")
+}
+
+// Revert deletes the keys of obj which the
+// engine created. Keys which existed before
+// keep the value they were set to.
+func (e redisEngine) Revert(ctx context.Context, obj jwalk.ObjectWalker) error {
+	keys := make([]string, 0)
+	if err := obj.Walk(func(key string, _ interface{}) error {
+		if e.created.take(key) {
+			keys = append(keys, key)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return e.Truncate(ctx, keys...)
+}
+
+func (c *created) add(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys[key] = true
+}
+
+// take reports whether the engine created
+// key and forgets it.
+func (c *created) take(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ok := c.keys[key]
+	delete(c.keys, key)
+	return ok
+}
+
+// Fetch reads back the values of the keys of obj.
+func (e redisEngine) Fetch(ctx context.Context, obj jwalk.ObjectWalker) (polluter.Record, error) {
+	cli := e.cli.WithContext(ctx)
+	doc := make(polluter.Record, 0)
+
+	if err := obj.Walk(func(key string, _ interface{}) error {
+		data, err := cli.Get(key).Bytes()
+		if err == redis.Nil {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "get %s", key)
+		}
+
+		doc = append(doc, polluter.Field{Name: key, Value: decode(data)})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// decode reads back a value set by polluter.
+// Values which are not JSON are strings.
+func decode(data []byte) interface{} {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil || d.More() {
+		return string(data)
+	}
+	return v
+}
+
+// Dump reads the keys matching the patterns
+// of the selections, sorted by name.
+func (e redisEngine) Dump(ctx context.Context, sel ...polluter.Selection) (polluter.Record, error) {
+	cli := e.cli.WithContext(ctx)
+	doc := make(polluter.Record, 0)
+
+	for _, s := range sel {
+		if s.Where != "" {
+			return nil, errors.New("redis does not support where")
+		}
+
+		keys := make([]string, 0)
+		iter := cli.Scan(0, s.Name, 0).Iterator()
+		for iter.Next() {
+			keys = append(keys, iter.Val())
+		}
+		if err := iter.Err(); err != nil {
+			return nil, errors.Wrapf(err, "scan %s", s.Name)
+		}
+		sort.Strings(keys)
+		if s.Limit > 0 && len(keys) > s.Limit {
+			keys = keys[:s.Limit]
+		}
+
+		for _, key := range keys {
+			if _, ok := doc.Get(key); ok {
+				continue
+			}
+
+			data, err := cli.Get(key).Bytes()
+			if err == redis.Nil {
+				continue
+			}
+			if err != nil {
+				return nil, errors.Wrapf(err, "get %s", key)
+			}
+
+			doc = append(doc, polluter.Field{Name: key, Value: decode(data)})
+		}
+	}
+
+	return doc, nil
+}
+
 // RedisEngine option enables
 // Redis engine for Polluter.
-func RedisEngine(cli *redis.Client) polluter.DbEngine {
-	return redisEngine{cli}
+func RedisEngine(cli *redis.Client, opts ...Option) polluter.DbEngine {
+	e := redisEngine{
+		cli:       cli,
+		snapshots: newSnapshots(),
+		created:   &created{keys: make(map[string]bool)},
+	}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return e
+}
+
+// Log option logs the commands executed
+// by the engine, see polluter.Log.
+func Log(l polluter.Logger, opts ...polluter.LogOption) Option {
+	return func(e *redisEngine) {
+		e.log = polluter.NewCommandLogger(l, opts...)
+	}
 }
@@ -2,6 +2,7 @@ package redis_test
 
 import (
 	"bytes"
+	"context"
 	"flag"
 	"github.com/ory/dockertest"
 	"github.com/quen2404/polluter"
@@ -52,19 +53,25 @@ func Test_redisEngine_build(t *testing.T) {
 			input: []byte(`{"count":1,"values":[1,2],"obj":{"key":"value"}}`),
 			expect: polluter.Commands{
 				{
-					Q: "count",
+					Q:     "count",
+					Table: "count",
+					Rows:  1,
 					Args: []interface{}{
 						[]byte(`1`),
 					},
 				},
 				{
-					Q: "values",
+					Q:     "values",
+					Table: "values",
+					Rows:  1,
 					Args: []interface{}{
 						[]byte(`[1,2]`),
 					},
 				},
 				{
-					Q: "obj",
+					Q:     "obj",
+					Table: "obj",
+					Rows:  1,
 					Args: []interface{}{
 						[]byte(`{"key":"value"}`),
 					},
@@ -139,3 +146,28 @@ func Test_redisEngine_exec(t *testing.T) {
 		})
 	}
 }
+
+func Test_redisEngine_revert(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	cli, teardown := db_test.PrepareRedisDB(t, 11)
+	defer func() {
+		_ = teardown()
+	}()
+	assert.Nil(t, cli.Set("count", "1", 0).Err())
+
+	e := redis.RedisEngine(cli)
+	obj, err := json.JSONParser().Parse(bytes.NewReader([]byte(`{"count":2,"name":"Roman"}`)))
+	if !assert.Nil(t, err) {
+		return
+	}
+	cmds, err := e.Build(obj)
+	assert.Nil(t, err)
+	assert.Nil(t, e.Exec(cmds))
+
+	assert.Nil(t, e.(polluter.Reverter).Revert(context.Background(), obj))
+	assert.Equal(t, "2", cli.Get("count").Val())
+	assert.Equal(t, int64(0), cli.Exists("name").Val())
+}
@@ -0,0 +1,120 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+)
+
+type (
+	// snapshots keeps the keys seeded by the
+	// engine and their dumps, by snapshot.
+	snapshots struct {
+		mu     sync.Mutex
+		seeded map[string]bool
+		saved  map[string]map[string]*dump
+	}
+
+	// dump is a serialized value, nil
+	// when the key did not exist.
+	dump struct {
+		value string
+		ttl   time.Duration
+	}
+)
+
+func newSnapshots() *snapshots {
+	return &snapshots{
+		seeded: make(map[string]bool),
+		saved:  make(map[string]map[string]*dump),
+	}
+}
+
+func (s *snapshots) seed(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seeded[key] = true
+}
+
+func (s *snapshots) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.seeded))
+	for k := range s.seeded {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Snapshot dumps the keys seeded by the
+// engine so far and keeps them in memory.
+func (e redisEngine) Snapshot(ctx context.Context, name string) error {
+	cli := e.cli.WithContext(ctx)
+
+	dumps := make(map[string]*dump)
+	for _, key := range e.snapshots.keys() {
+		value, err := cli.Dump(key).Result()
+		if err == redis.Nil {
+			dumps[key] = nil
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "dump %s", key)
+		}
+
+		ttl, err := cli.PTTL(key).Result()
+		if err != nil {
+			return errors.Wrapf(err, "ttl of %s", key)
+		}
+		if ttl < 0 {
+			ttl = 0
+		}
+		dumps[key] = &dump{value: value, ttl: ttl}
+	}
+
+	e.snapshots.mu.Lock()
+	e.snapshots.saved[name] = dumps
+	e.snapshots.mu.Unlock()
+	return nil
+}
+
+// Restore restores the dumped keys and
+// deletes the ones seeded afterwards, or
+// which did not exist then.
+func (e redisEngine) Restore(ctx context.Context, name string) error {
+	e.snapshots.mu.Lock()
+	dumps, ok := e.snapshots.saved[name]
+	e.snapshots.mu.Unlock()
+	if !ok {
+		return errors.Errorf("no snapshot %s", name)
+	}
+
+	cli := e.cli.WithContext(ctx)
+	for _, key := range e.snapshots.keys() {
+		d := dumps[key]
+		if d == nil {
+			if err := cli.Del(key).Err(); err != nil {
+				return errors.Wrapf(err, "delete %s", key)
+			}
+			continue
+		}
+		if err := cli.RestoreReplace(key, d.ttl, d.value).Err(); err != nil {
+			return errors.Wrapf(err, "restore %s", key)
+		}
+	}
+	return nil
+}
+
+// DropSnapshot forgets the dumped keys.
+func (e redisEngine) DropSnapshot(_ context.Context, name string) error {
+	e.snapshots.mu.Lock()
+	defer e.snapshots.mu.Unlock()
+	delete(e.snapshots.saved, name)
+	return nil
+}
@@ -0,0 +1,40 @@
+package redis_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/database/redis"
+	"github.com/quen2404/polluter/internal/db_test"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_redisEngine_snapshot(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	ctx := context.Background()
+	cli, teardown := db_test.PrepareRedisDB(t, 10)
+	defer func() {
+		_ = teardown()
+	}()
+
+	e := redis.RedisEngine(cli)
+	s := e.(polluter.Snapshotter)
+
+	assert.Nil(t, e.Exec(polluter.Commands{{Q: "count", Args: []interface{}{"1"}}}))
+	assert.Nil(t, s.Snapshot(ctx, "base"))
+
+	assert.Nil(t, e.Exec(polluter.Commands{{Q: "count", Args: []interface{}{"2"}}, {Q: "name", Args: []interface{}{"Roman"}}}))
+	assert.Nil(t, s.Restore(ctx, "base"))
+
+	count, err := cli.Get("count").Result()
+	assert.Nil(t, err)
+	assert.Equal(t, "1", count)
+	assert.Equal(t, int64(0), cli.Exists("name").Val())
+
+	assert.Nil(t, s.DropSnapshot(ctx, "base"))
+	assert.NotNil(t, s.Restore(ctx, "base"))
+}
@@ -0,0 +1,245 @@
+package sqldb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/quen2404/polluter"
+	"github.com/romanyx/jwalk"
+)
+
+type (
+	// kind groups the column types
+	// values are coerced to.
+	kind int
+
+	// schema holds the types of the columns,
+	// by table, loaded once per engine.
+	schema struct {
+		mu    sync.Mutex
+		types map[string]map[string]kind
+	}
+)
+
+const (
+	other kind = iota
+	boolean
+	integer
+	decimal
+	timestamp
+	date
+	binary
+	uuid
+)
+
+var kinds = map[string]kind{
+	"bool":             boolean,
+	"boolean":          boolean,
+	"bit":              boolean,
+	"tinyint(1)":       boolean,
+	"int":              integer,
+	"integer":          integer,
+	"tinyint":          integer,
+	"smallint":         integer,
+	"mediumint":        integer,
+	"bigint":           integer,
+	"int2":             integer,
+	"int4":             integer,
+	"int8":             integer,
+	"decimal":          decimal,
+	"numeric":          decimal,
+	"money":            decimal,
+	"smallmoney":       decimal,
+	"timestamp":        timestamp,
+	"timestamptz":      timestamp,
+	"datetime":         timestamp,
+	"datetime2":        timestamp,
+	"datetimeoffset":   timestamp,
+	"smalldatetime":    timestamp,
+	"date":             date,
+	"bytea":            binary,
+	"blob":             binary,
+	"tinyblob":         binary,
+	"mediumblob":       binary,
+	"longblob":         binary,
+	"binary":           binary,
+	"varbinary":        binary,
+	"image":            binary,
+	"uuid":             uuid,
+	"uniqueidentifier": uuid,
+}
+
+// kindOf returns the kind of a column type
+// as the Columns query of a dialect lists it.
+func kindOf(t string) kind {
+	t = strings.ToLower(strings.TrimSpace(t))
+	if k, ok := kinds[t]; ok {
+		return k
+	}
+	if i := strings.IndexAny(t, "( "); i >= 0 {
+		return kinds[t[:i]]
+	}
+	return other
+}
+
+// load reads the column types once, and
+// again after a failure.
+func (s *schema) load(ctx context.Context, db DB, d Dialect) (map[string]map[string]kind, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.types != nil {
+		return s.types, nil
+	}
+	types, err := columnTypes(ctx, db, d)
+	if err != nil {
+		return nil, err
+	}
+	s.types = types
+	return types, nil
+}
+
+func columnTypes(ctx context.Context, db DB, d Dialect) (map[string]map[string]kind, error) {
+	columns, err := readColumns(ctx, db, d)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[string]map[string]kind)
+	for _, c := range columns {
+		if types[c.table] == nil {
+			types[c.table] = make(map[string]kind)
+		}
+		types[c.table][c.Name] = kindOf(c.typ)
+	}
+	return types, nil
+}
+
+// column is a row of the Columns
+// query of a dialect.
+type column struct {
+	polluter.Column
+	table string
+	typ   string
+}
+
+func readColumns(ctx context.Context, db DB, d Dialect) ([]column, error) {
+	rows, err := db.QueryContext(ctx, d.Columns())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make([]column, 0)
+	for rows.Next() {
+		var c column
+		if err := rows.Scan(&c.table, &c.Name, &c.typ, &c.Required); err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+
+	return columns, rows.Err()
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// coerce converts a fixture value to the Go
+// type drivers expect for a column of kind k.
+// Values which are already right, and nulls,
+// are left as they are.
+func coerce(k kind, value jwalk.Value) (interface{}, error) {
+	v := value.Interface()
+	if v == nil {
+		return nil, nil
+	}
+
+	switch k {
+	case boolean:
+		switch b := v.(type) {
+		case float64:
+			return b != 0, nil
+		case string:
+			parsed, err := strconv.ParseBool(b)
+			return parsed, errors.Wrapf(err, "invalid boolean %q", b)
+		}
+	case integer:
+		switch n := v.(type) {
+		case bool:
+			if n {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case float64:
+			if n == math.Trunc(n) && math.Abs(n) < 1<<53 {
+				return int64(n), nil
+			}
+			text := numberText(value, n)
+			parsed, err := strconv.ParseInt(text, 10, 64)
+			return parsed, errors.Wrapf(err, "invalid integer %s", text)
+		}
+	case decimal:
+		if n, ok := v.(float64); ok {
+			return numberText(value, n), nil
+		}
+	case timestamp, date:
+		if s, ok := v.(string); ok {
+			for _, layout := range timeLayouts {
+				if t, err := time.Parse(layout, s); err == nil {
+					return t, nil
+				}
+			}
+			return nil, errors.Errorf("invalid time %q", s)
+		}
+	case binary:
+		if s, ok := v.(string); ok {
+			data, err := base64.StdEncoding.DecodeString(s)
+			return data, errors.Wrap(err, "invalid base64")
+		}
+	case uuid:
+		if s, ok := v.(string); ok {
+			return parseUUID(s)
+		}
+	}
+
+	return v, nil
+}
+
+// numberText returns the text of a number
+// as the fixture has it, which keeps the
+// digits a float64 loses.
+func numberText(value jwalk.Value, n float64) string {
+	if m, ok := value.(json.Marshaler); ok {
+		if data, err := m.MarshalJSON(); err == nil {
+			return string(data)
+		}
+	}
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// parseUUID returns the canonical form of
+// a UUID, with or without hyphens or braces.
+func parseUUID(s string) (string, error) {
+	digits := strings.ToLower(strings.NewReplacer("-", "", "{", "", "}", "").Replace(s))
+	if len(digits) != 32 {
+		return "", errors.Errorf("invalid UUID %q", s)
+	}
+	if _, err := hex.DecodeString(digits); err != nil {
+		return "", errors.Errorf("invalid UUID %q", s)
+	}
+	return digits[:8] + "-" + digits[8:12] + "-" + digits[12:16] + "-" + digits[16:20] + "-" + digits[20:], nil
+}
@@ -0,0 +1,82 @@
+package sqldb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/romanyx/jwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_kindOf(t *testing.T) {
+	tests := map[string]kind{
+		"tinyint(1)":                  boolean,
+		"tinyint(4)":                  integer,
+		"bigint(20) unsigned":         integer,
+		"decimal(10,2)":               decimal,
+		"timestamptz":                 timestamp,
+		"DATETIME":                    timestamp,
+		"date":                        date,
+		"bytea":                       binary,
+		"varbinary(16)":               binary,
+		"uniqueidentifier":            uuid,
+		"varchar(255)":                other,
+		"timestamp without time zone": timestamp,
+	}
+
+	for typ, expect := range tests {
+		assert.Equal(t, expect, kindOf(typ), typ)
+	}
+}
+
+func Test_coerce(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    kind
+		input   string
+		expect  interface{}
+		wantErr bool
+	}{
+		{name: "null", kind: timestamp, input: `null`, expect: nil},
+		{name: "other", kind: other, input: `"Roman"`, expect: "Roman"},
+		{name: "boolean from number", kind: boolean, input: `1`, expect: true},
+		{name: "boolean from string", kind: boolean, input: `"false"`, expect: false},
+		{name: "invalid boolean", kind: boolean, input: `"maybe"`, wantErr: true},
+		{name: "integer from boolean", kind: integer, input: `true`, expect: int64(1)},
+		{name: "integer", kind: integer, input: `42`, expect: int64(42)},
+		{name: "big integer", kind: integer, input: `9007199254740993`, expect: int64(9007199254740993)},
+		{name: "decimal", kind: decimal, input: `12345678901234567.89`, expect: "12345678901234567.89"},
+		{name: "timestamp", kind: timestamp, input: `"2020-01-02T03:04:05Z"`, expect: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "timestamp without zone", kind: timestamp, input: `"2020-01-02 03:04:05"`, expect: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{name: "date", kind: date, input: `"2020-01-02"`, expect: time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "invalid time", kind: date, input: `"yesterday"`, wantErr: true},
+		{name: "binary", kind: binary, input: `"aGVsbG8="`, expect: []byte("hello")},
+		{name: "invalid binary", kind: binary, input: `"!"`, wantErr: true},
+		{name: "uuid", kind: uuid, input: `"{E3B0C442-98FC-1C14-9AFB-4C8996FB9242}"`, expect: "e3b0c442-98fc-1c14-9afb-4c8996fb9242"},
+		{name: "invalid uuid", kind: uuid, input: `"e3b0c442"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := jwalk.Parse([]byte(`{"v":` + tt.input + `}`))
+			assert.Nil(t, err)
+
+			var value jwalk.Value
+			assert.Nil(t, v.(jwalk.ObjectWalker).Walk(func(_ string, i interface{}) error {
+				value = i.(jwalk.Value)
+				return nil
+			}))
+
+			got, err := coerce(tt.kind, value)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expect, got)
+		})
+	}
+}
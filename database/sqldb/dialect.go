@@ -0,0 +1,380 @@
+package sqldb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Dialect describes the SQL of a database.
+	Dialect interface {
+		// Quote quotes an identifier.
+		Quote(name string) string
+		// Placeholder returns the placeholder
+		// of the n-th argument, starting at 1.
+		Placeholder(n int) string
+		// Insert returns the INSERT statement of ins.
+		Insert(ins Insert) (string, error)
+		// Truncate returns the statements emptying
+		// the tables, run on a single connection:
+		// the body is run after the setup, and the
+		// teardown, which restores the settings of
+		// the connection, is run in any case.
+		Truncate(tables []string) (setup, body, teardown []string)
+		// Limit returns the clause ending a
+		// SELECT which returns n rows at most.
+		Limit(n int) string
+		// ForeignKeys returns a query listing the
+		// tables of the current schema along with
+		// the tables they reference.
+		ForeignKeys() string
+		// Columns returns a query listing the
+		// table, name, type and whether a value is
+		// required, NOT NULL without a default,
+		// of the columns of the tables of the
+		// current schema, in order.
+		Columns() string
+		// Savepoint returns the statements which
+		// set, roll back to and release a savepoint,
+		// the last one empty when not needed.
+		Savepoint(name string) (set, rollback, release string)
+	}
+
+	// Insert describes an INSERT statement.
+	Insert struct {
+		Table   string
+		Columns []string
+		// Values are the rows to insert, made of
+		// placeholders or expressions, one per
+		// column.
+		Values [][]string
+		// Upsert updates the rows conflicting
+		// on the Keys columns, if any.
+		Upsert bool
+		Keys   []string
+		// Update lists the columns an upsert
+		// updates, all of them when nil.
+		Update []string
+		// Returning lists the columns of
+		// the inserted rows to return.
+		Returning []string
+	}
+)
+
+var (
+	// MySQL dialect quotes with backticks
+	// and upserts on any unique key.
+	MySQL Dialect = mysqlDialect{}
+	// Postgres dialect numbers its placeholders
+	// and upserts on conflicting keys.
+	Postgres Dialect = postgresDialect{}
+	// SQLite dialect works like the Postgres
+	// one with question mark placeholders.
+	SQLite Dialect = sqliteDialect{}
+	// SQLServer dialect quotes with brackets
+	// and upserts with MERGE.
+	SQLServer Dialect = sqlserverDialect{}
+)
+
+type (
+	mysqlDialect     struct{}
+	postgresDialect  struct{}
+	sqliteDialect    struct{ postgresDialect }
+	sqlserverDialect struct{}
+)
+
+func (mysqlDialect) Quote(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (mysqlDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (d mysqlDialect) Insert(ins Insert) (string, error) {
+	if len(ins.Returning) > 0 {
+		return "", errors.New("mysql does not support returning")
+	}
+
+	q := insert(d, ins)
+	if ins.Upsert && len(ins.Columns) > 0 {
+		update := updated(ins)
+		set := make([]string, len(update))
+		for i, c := range update {
+			set[i] = fmt.Sprintf("%s = VALUES(%s)", d.Quote(c), d.Quote(c))
+		}
+		if len(set) == 0 {
+			// MySQL needs an assignment, which
+			// leaves the row as it is.
+			c := d.Quote(ins.Columns[0])
+			set = []string{fmt.Sprintf("%s = %s", c, c)}
+		}
+		q = q + " ON DUPLICATE KEY UPDATE " + strings.Join(set, ", ")
+	}
+
+	return q + ";", nil
+}
+
+func (d mysqlDialect) Truncate(tables []string) ([]string, []string, []string) {
+	stmts := make([]string, 0, len(tables))
+	for _, t := range tables {
+		stmts = append(stmts, fmt.Sprintf("TRUNCATE TABLE %s;", d.Quote(t)))
+	}
+	return []string{"SET FOREIGN_KEY_CHECKS = 0;"}, stmts, []string{"SET FOREIGN_KEY_CHECKS = 1;"}
+}
+
+func (mysqlDialect) Limit(n int) string {
+	return fmt.Sprintf(" LIMIT %d", n)
+}
+
+func (mysqlDialect) ForeignKeys() string {
+	return `SELECT TABLE_NAME, REFERENCED_TABLE_NAME
+		FROM information_schema.KEY_COLUMN_USAGE
+		WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL;`
+}
+
+func (mysqlDialect) Columns() string {
+	return `SELECT TABLE_NAME, COLUMN_NAME, COLUMN_TYPE,
+			IS_NULLABLE = 'NO' AND COLUMN_DEFAULT IS NULL AND EXTRA NOT LIKE '%auto_increment%' AND EXTRA NOT LIKE '%GENERATED%'
+		FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE()
+		ORDER BY TABLE_NAME, ORDINAL_POSITION;`
+}
+
+func (d mysqlDialect) Savepoint(name string) (string, string, string) {
+	return savepoint(d, name)
+}
+
+func (postgresDialect) Quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (postgresDialect) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (d postgresDialect) Insert(ins Insert) (string, error) {
+	return insert(d, ins) + onConflict(d, ins) + returning(d, ins) + ";", nil
+}
+
+func (d postgresDialect) Truncate(tables []string) ([]string, []string, []string) {
+	return nil, []string{fmt.Sprintf("TRUNCATE %s RESTART IDENTITY;", quoteAll(d, tables))}, nil
+}
+
+func (postgresDialect) Limit(n int) string {
+	return fmt.Sprintf(" LIMIT %d", n)
+}
+
+func (postgresDialect) ForeignKeys() string {
+	return `SELECT tc.table_name, ccu.table_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.constraint_column_usage ccu
+			ON tc.constraint_name = ccu.constraint_name AND tc.constraint_schema = ccu.constraint_schema
+		WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema();`
+}
+
+func (postgresDialect) Columns() string {
+	return `SELECT table_name, column_name, udt_name,
+			is_nullable = 'NO' AND column_default IS NULL AND is_identity = 'NO' AND is_generated = 'NEVER'
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()
+		ORDER BY table_name, ordinal_position;`
+}
+
+func (d postgresDialect) Savepoint(name string) (string, string, string) {
+	return savepoint(d, name)
+}
+
+func (sqliteDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (d sqliteDialect) Insert(ins Insert) (string, error) {
+	return insert(d, ins) + onConflict(d, ins) + returning(d, ins) + ";", nil
+}
+
+func (d sqliteDialect) Truncate(tables []string) ([]string, []string, []string) {
+	stmts := make([]string, 0, len(tables))
+	for _, t := range tables {
+		stmts = append(stmts, fmt.Sprintf("DELETE FROM %s;", d.Quote(t)))
+	}
+	return []string{"PRAGMA foreign_keys = OFF;"}, stmts, []string{"PRAGMA foreign_keys = ON;"}
+}
+
+func (sqliteDialect) ForeignKeys() string {
+	return `SELECT m.name, p."table"
+		FROM sqlite_master m JOIN pragma_foreign_key_list(m.name) p
+		WHERE m.type = 'table';`
+}
+
+func (sqliteDialect) Columns() string {
+	return `SELECT m.name, p.name, p.type,
+			p."notnull" = 1 AND p.dflt_value IS NULL AND NOT (p.pk = 1 AND upper(p.type) = 'INTEGER')
+		FROM sqlite_master m JOIN pragma_table_info(m.name) p
+		WHERE m.type = 'table'
+		ORDER BY m.name, p.cid;`
+}
+
+func (sqlserverDialect) Quote(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+func (sqlserverDialect) Placeholder(n int) string {
+	return fmt.Sprintf("@p%d", n)
+}
+
+func (d sqlserverDialect) Insert(ins Insert) (string, error) {
+	output := ""
+	if len(ins.Returning) > 0 {
+		cols := make([]string, len(ins.Returning))
+		for i, c := range ins.Returning {
+			cols[i] = "INSERTED." + d.Quote(c)
+		}
+		output = " OUTPUT " + strings.Join(cols, ", ")
+	}
+
+	if !ins.Upsert || len(ins.Keys) == 0 {
+		return fmt.Sprintf("INSERT INTO %s (%s)%s VALUES %s;",
+			d.Quote(ins.Table), quoteAll(d, ins.Columns), output, valuesList(ins.Values)), nil
+	}
+
+	on := make([]string, len(ins.Keys))
+	for i, k := range ins.Keys {
+		on[i] = fmt.Sprintf("target.%s = source.%s", d.Quote(k), d.Quote(k))
+	}
+	source := make([]string, len(ins.Columns))
+	for i, c := range ins.Columns {
+		source[i] = "source." + d.Quote(c)
+	}
+	set := make([]string, 0, len(ins.Columns))
+	for _, c := range updated(ins) {
+		if !contains(ins.Keys, c) {
+			set = append(set, fmt.Sprintf("target.%s = source.%s", d.Quote(c), d.Quote(c)))
+		}
+	}
+
+	q := fmt.Sprintf("MERGE INTO %s AS target USING (VALUES %s) AS source (%s) ON %s",
+		d.Quote(ins.Table), valuesList(ins.Values), quoteAll(d, ins.Columns), strings.Join(on, " AND "))
+	if len(set) > 0 {
+		q = q + " WHEN MATCHED THEN UPDATE SET " + strings.Join(set, ", ")
+	}
+	q = q + fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s)", quoteAll(d, ins.Columns), strings.Join(source, ", "))
+
+	return q + output + ";", nil
+}
+
+func (d sqlserverDialect) Truncate(tables []string) ([]string, []string, []string) {
+	stmts := make([]string, 0, len(tables))
+	for _, t := range tables {
+		stmts = append(stmts, fmt.Sprintf("DELETE FROM %s;", d.Quote(t)))
+	}
+	return nil, stmts, nil
+}
+
+func (sqlserverDialect) Limit(n int) string {
+	return fmt.Sprintf(" ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT %d ROWS ONLY", n)
+}
+
+func (sqlserverDialect) Columns() string {
+	return `SELECT TABLE_NAME, COLUMN_NAME, DATA_TYPE,
+			CASE WHEN IS_NULLABLE = 'NO' AND COLUMN_DEFAULT IS NULL
+				AND COLUMNPROPERTY(OBJECT_ID(QUOTENAME(TABLE_SCHEMA) + '.' + QUOTENAME(TABLE_NAME)), COLUMN_NAME, 'IsIdentity') = 0
+				AND COLUMNPROPERTY(OBJECT_ID(QUOTENAME(TABLE_SCHEMA) + '.' + QUOTENAME(TABLE_NAME)), COLUMN_NAME, 'IsComputed') = 0
+				AND DATA_TYPE NOT IN ('timestamp', 'rowversion')
+			THEN 1 ELSE 0 END
+		FROM INFORMATION_SCHEMA.COLUMNS
+		WHERE TABLE_SCHEMA = SCHEMA_NAME()
+		ORDER BY TABLE_NAME, ORDINAL_POSITION;`
+}
+
+func (sqlserverDialect) ForeignKeys() string {
+	return `SELECT tp.name, tr.name
+		FROM sys.foreign_keys fk
+		JOIN sys.tables tp ON fk.parent_object_id = tp.object_id
+		JOIN sys.tables tr ON fk.referenced_object_id = tr.object_id;`
+}
+
+func (d sqlserverDialect) Savepoint(name string) (string, string, string) {
+	name = d.Quote(name)
+	return fmt.Sprintf("SAVE TRANSACTION %s;", name), fmt.Sprintf("ROLLBACK TRANSACTION %s;", name), ""
+}
+
+// savepoint returns the standard
+// savepoint statements.
+func savepoint(d Dialect, name string) (string, string, string) {
+	name = d.Quote(name)
+	return fmt.Sprintf("SAVEPOINT %s;", name),
+		fmt.Sprintf("ROLLBACK TO SAVEPOINT %s;", name),
+		fmt.Sprintf("RELEASE SAVEPOINT %s;", name)
+}
+
+// insert returns the plain INSERT statement
+// of ins, without the final semicolon.
+func insert(d Dialect, ins Insert) string {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", d.Quote(ins.Table), quoteAll(d, ins.Columns), valuesList(ins.Values))
+}
+
+// valuesList joins rows of values
+// as in (a, b), (c, d).
+func valuesList(rows [][]string) string {
+	list := make([]string, len(rows))
+	for i, r := range rows {
+		list[i] = "(" + strings.Join(r, ", ") + ")"
+	}
+	return strings.Join(list, ", ")
+}
+
+// updated returns the columns
+// an upsert updates.
+func updated(ins Insert) []string {
+	if ins.Update != nil {
+		return ins.Update
+	}
+	return ins.Columns
+}
+
+// onConflict returns the ON CONFLICT
+// clause of Postgres and SQLite.
+func onConflict(d Dialect, ins Insert) string {
+	if !ins.Upsert || len(ins.Keys) == 0 {
+		return ""
+	}
+
+	set := make([]string, 0, len(ins.Columns))
+	for _, c := range updated(ins) {
+		if !contains(ins.Keys, c) {
+			set = append(set, fmt.Sprintf("%s = EXCLUDED.%s", d.Quote(c), d.Quote(c)))
+		}
+	}
+	if len(set) == 0 {
+		return fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", quoteAll(d, ins.Keys))
+	}
+
+	return fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", quoteAll(d, ins.Keys), strings.Join(set, ", "))
+}
+
+func returning(d Dialect, ins Insert) string {
+	if len(ins.Returning) == 0 {
+		return ""
+	}
+	return " RETURNING " + quoteAll(d, ins.Returning)
+}
+
+func quoteAll(d Dialect, names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = d.Quote(n)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+package sqldb_test
+
+import (
+	"testing"
+
+	"github.com/quen2404/polluter/database/sqldb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDialect_Insert(t *testing.T) {
+	plain := sqldb.Insert{Table: "users", Columns: []string{"id", "name"}}
+	upsert := sqldb.Insert{Table: "users", Columns: []string{"id", "name"}, Upsert: true, Keys: []string{"id"}}
+	returning := sqldb.Insert{Table: "users", Columns: []string{"name"}, Returning: []string{"id"}}
+
+	tests := []struct {
+		name    string
+		dialect sqldb.Dialect
+		ins     sqldb.Insert
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:    "mysql",
+			dialect: sqldb.MySQL,
+			ins:     plain,
+			expect:  "INSERT INTO `users` (`id`, `name`) VALUES (?, ?);",
+		},
+		{
+			name:    "mysql upsert",
+			dialect: sqldb.MySQL,
+			ins:     upsert,
+			expect:  "INSERT INTO `users` (`id`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`);",
+		},
+		{
+			name:    "mysql returning",
+			dialect: sqldb.MySQL,
+			ins:     returning,
+			wantErr: true,
+		},
+		{
+			name:    "postgres upsert",
+			dialect: sqldb.Postgres,
+			ins:     upsert,
+			expect:  `INSERT INTO "users" ("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name";`,
+		},
+		{
+			name:    "postgres returning",
+			dialect: sqldb.Postgres,
+			ins:     returning,
+			expect:  `INSERT INTO "users" ("name") VALUES ($1) RETURNING "id";`,
+		},
+		{
+			name:    "sqlite upsert",
+			dialect: sqldb.SQLite,
+			ins:     upsert,
+			expect:  `INSERT INTO "users" ("id", "name") VALUES (?, ?) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name";`,
+		},
+		{
+			name:    "sqlserver",
+			dialect: sqldb.SQLServer,
+			ins:     plain,
+			expect:  "INSERT INTO [users] ([id], [name]) VALUES (@p1, @p2);",
+		},
+		{
+			name:    "sqlserver returning",
+			dialect: sqldb.SQLServer,
+			ins:     returning,
+			expect:  "INSERT INTO [users] ([name]) OUTPUT INSERTED.[id] VALUES (@p1);",
+		},
+		{
+			name:    "sqlserver upsert",
+			dialect: sqldb.SQLServer,
+			ins:     upsert,
+			expect: "MERGE INTO [users] AS target USING (VALUES (@p1, @p2)) AS source ([id], [name]) ON target.[id] = source.[id]" +
+				" WHEN MATCHED THEN UPDATE SET target.[name] = source.[name]" +
+				" WHEN NOT MATCHED THEN INSERT ([id], [name]) VALUES (source.[id], source.[name]);",
+		},
+		{
+			name:    "sqlserver upsert of keys",
+			dialect: sqldb.SQLServer,
+			ins:     sqldb.Insert{Table: "users", Columns: []string{"id", "name"}, Upsert: true, Keys: []string{"id"}, Update: []string{"id"}},
+			expect: "MERGE INTO [users] AS target USING (VALUES (@p1, @p2)) AS source ([id], [name]) ON target.[id] = source.[id]" +
+				" WHEN NOT MATCHED THEN INSERT ([id], [name]) VALUES (source.[id], source.[name]);",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ins := tt.ins
+			values := make([]string, 0, len(ins.Columns))
+			for i := range ins.Columns {
+				values = append(values, tt.dialect.Placeholder(i+1))
+			}
+			ins.Values = [][]string{values}
+
+			got, err := tt.dialect.Insert(ins)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expect, got)
+		})
+	}
+}
+
+func TestDialect_Truncate(t *testing.T) {
+	tables := []string{"users", "roles"}
+
+	tests := []struct {
+		name     string
+		dialect  sqldb.Dialect
+		setup    []string
+		body     []string
+		teardown []string
+	}{
+		{
+			name:     "mysql",
+			dialect:  sqldb.MySQL,
+			setup:    []string{"SET FOREIGN_KEY_CHECKS = 0;"},
+			body:     []string{"TRUNCATE TABLE `users`;", "TRUNCATE TABLE `roles`;"},
+			teardown: []string{"SET FOREIGN_KEY_CHECKS = 1;"},
+		},
+		{
+			name:    "postgres",
+			dialect: sqldb.Postgres,
+			body:    []string{`TRUNCATE "users", "roles" RESTART IDENTITY;`},
+		},
+		{
+			name:     "sqlite",
+			dialect:  sqldb.SQLite,
+			setup:    []string{"PRAGMA foreign_keys = OFF;"},
+			body:     []string{`DELETE FROM "users";`, `DELETE FROM "roles";`},
+			teardown: []string{"PRAGMA foreign_keys = ON;"},
+		},
+		{
+			name:    "sqlserver",
+			dialect: sqldb.SQLServer,
+			body:    []string{"DELETE FROM [users];", "DELETE FROM [roles];"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			setup, body, teardown := tt.dialect.Truncate(tables)
+			assert.Equal(t, tt.setup, setup)
+			assert.Equal(t, tt.body, body)
+			assert.Equal(t, tt.teardown, teardown)
+		})
+	}
+}
+
+func TestDialect_Savepoint(t *testing.T) {
+	set, rollback, release := sqldb.Postgres.Savepoint("polluter")
+	assert.Equal(t, []string{`SAVEPOINT "polluter";`, `ROLLBACK TO SAVEPOINT "polluter";`, `RELEASE SAVEPOINT "polluter";`}, []string{set, rollback, release})
+
+	set, rollback, release = sqldb.MySQL.Savepoint("polluter")
+	assert.Equal(t, []string{"SAVEPOINT `polluter`;", "ROLLBACK TO SAVEPOINT `polluter`;", "RELEASE SAVEPOINT `polluter`;"}, []string{set, rollback, release})
+
+	set, rollback, release = sqldb.SQLServer.Savepoint("polluter")
+	assert.Equal(t, []string{"SAVE TRANSACTION [polluter];", "ROLLBACK TRANSACTION [polluter];", ""}, []string{set, rollback, release})
+}
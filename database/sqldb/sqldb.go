@@ -0,0 +1,615 @@
+package sqldb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/quen2404/polluter"
+	"github.com/romanyx/jwalk"
+)
+
+type (
+	// DB executes statements: a *sql.DB,
+	// a *sql.Conn or a *sql.Tx.
+	DB interface {
+		ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+		QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	}
+
+	beginner interface {
+		BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	}
+
+	// tx is a transaction, or a savepoint
+	// of a transaction given to the engine.
+	tx struct {
+		DB
+		commit   func() error
+		rollback func() error
+	}
+
+	sqlEngine struct {
+		db        DB
+		dialect   Dialect
+		upsert    []string
+		returning []string
+		returned  func(polluter.Record)
+		schema    *schema
+		normalize bool
+		log       *polluter.CommandLogger
+	}
+
+	// Option configures the SQL engine.
+	Option func(*sqlEngine)
+)
+
+func (e sqlEngine) Exec(cmds polluter.Commands) error {
+	return e.ExecContext(context.Background(), cmds)
+}
+
+// ExecContext executes the commands in a
+// transaction, rolled back on failure.
+// Given a *sql.Tx, the engine uses a
+// savepoint instead and leaves the
+// transaction open.
+func (e sqlEngine) ExecContext(ctx context.Context, cmds polluter.Commands) error {
+	ctx = e.log.Context(ctx)
+	tx, err := e.begin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "tx begin")
+	}
+
+	for _, c := range cmds {
+		if err := polluter.RunCommand(ctx, c, func(c polluter.Command) error {
+			return e.run(ctx, tx, c)
+		}); err != nil {
+			if rErr := tx.rollback(); rErr != nil {
+				err = errors.Wrap(rErr, err.Error())
+			}
+			return errors.Wrap(err, "exec")
+		}
+	}
+
+	return errors.Wrap(tx.commit(), "commit")
+}
+
+// savepoint names the savepoint set when
+// the engine is given a transaction.
+const savepointName = "polluter"
+
+// begin starts a transaction on a database or
+// a connection, or sets a savepoint otherwise.
+func (e sqlEngine) begin(ctx context.Context) (tx, error) {
+	if b, ok := e.db.(beginner); ok {
+		t, err := b.BeginTx(ctx, nil)
+		if err != nil {
+			return tx{}, err
+		}
+		return tx{DB: t, commit: t.Commit, rollback: t.Rollback}, nil
+	}
+
+	set, rollback, release := e.dialect.Savepoint(savepointName)
+	if _, err := e.db.ExecContext(ctx, set); err != nil {
+		return tx{}, err
+	}
+	exec := func(q string) func() error {
+		return func() error {
+			if q == "" {
+				return nil
+			}
+			_, err := e.db.ExecContext(ctx, q)
+			return err
+		}
+	}
+	return tx{DB: e.db, commit: exec(release), rollback: exec(rollback)}, nil
+}
+
+func (e sqlEngine) run(ctx context.Context, tx DB, c polluter.Command) error {
+	if e.returned == nil {
+		_, err := tx.ExecContext(ctx, c.Q, c.Args...)
+		return err
+	}
+
+	rows, err := tx.QueryContext(ctx, c.Q, c.Args...)
+	if err != nil {
+		return err
+	}
+	records, err := polluter.ScanRecords(rows)
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		e.returned(r)
+	}
+	return nil
+}
+
+func (e sqlEngine) Build(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+	return e.BuildContext(context.Background(), obj)
+}
+
+// BuildContext builds an INSERT per row of each
+// table, or multi-row INSERTs with Normalize.
+// Raw SQL values are written in the
+// statement, null values are bound as NULL.
+// Other nested objects and arrays of objects
+// are not columns and are left out. SQLite
+// does not support DEFAULT values. ctx is
+// used to read the column types for Coerce.
+func (e sqlEngine) BuildContext(ctx context.Context, obj jwalk.ObjectWalker) (polluter.Commands, error) {
+	var types map[string]map[string]kind
+	if e.schema != nil {
+		var err error
+		if types, err = e.schema.load(ctx, e.db, e.dialect); err != nil {
+			return nil, errors.Wrap(err, "column types")
+		}
+	}
+
+	cmds := make(polluter.Commands, 0)
+
+	if err := obj.Walk(func(table string, value interface{}) error {
+		v, ok := value.(jwalk.ObjectsWalker)
+		if !ok {
+			return nil
+		}
+
+		rows := make([]row, 0)
+		if err := v.Walk(func(obj jwalk.ObjectWalker) error {
+			r, err := readRow(obj)
+			rows = append(rows, r)
+			return err
+		}); err != nil {
+			return errors.Wrap(err, table)
+		}
+
+		if !e.normalize {
+			for _, r := range rows {
+				c, err := e.insert(table, r.columns, []row{r}, types[table])
+				if err != nil {
+					return errors.Wrap(err, table)
+				}
+				cmds = append(cmds, c)
+			}
+			return nil
+		}
+
+		columns, err := polluter.Columns(v)
+		if err != nil {
+			return errors.Wrap(err, table)
+		}
+		for _, batch := range e.batches(rows, len(columns)) {
+			c, err := e.insert(table, columns, batch, types[table])
+			if err != nil {
+				return errors.Wrap(err, table)
+			}
+			cmds = append(cmds, c)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return cmds, nil
+}
+
+const (
+	// maxArgs bounds the placeholders of an
+	// INSERT, under the limit of SQLite.
+	maxArgs = 999
+	// maxRows bounds the rows of an INSERT,
+	// the limit of SQL Server.
+	maxRows = 1000
+)
+
+type (
+	// expr is a raw SQL value of a row.
+	expr string
+
+	// row holds the columns a row gives
+	// and their jwalk.Value or expr values.
+	row struct {
+		columns []string
+		values  map[string]interface{}
+	}
+)
+
+func readRow(obj jwalk.ObjectWalker) (row, error) {
+	r := row{values: make(map[string]interface{})}
+	err := obj.Walk(func(field string, value interface{}) error {
+		if raw, ok := polluter.RawSQL(value); ok {
+			r.columns = append(r.columns, field)
+			r.values[field] = expr(raw)
+		} else if v, ok := value.(jwalk.Value); ok {
+			r.columns = append(r.columns, field)
+			r.values[field] = v
+		}
+		return nil
+	})
+	return r, err
+}
+
+// batches splits the rows of a table into
+// multi-row INSERTs of width columns. Rows
+// which give different columns are upserted
+// apart, so that the columns filled with
+// DEFAULT are not updated.
+func (e sqlEngine) batches(rows []row, width int) [][]row {
+	size := maxRows
+	if width > 0 && maxArgs/width < size {
+		size = maxArgs / width
+	}
+	if size < 1 {
+		size = 1
+	}
+
+	batches := make([][]row, 0)
+	start := 0
+	for i := 1; i <= len(rows); i++ {
+		if i < len(rows) && i-start < size &&
+			(len(e.upsert) == 0 || sameColumns(rows[start].columns, rows[i].columns)) {
+			continue
+		}
+		batches = append(batches, rows[start:i])
+		start = i
+	}
+	return batches
+}
+
+// sameColumns reports whether a
+// and b hold the same columns.
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, c := range a {
+		if !contains(b, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// insert builds the INSERT of rows with the
+// given columns, filling the ones a row omits
+// with DEFAULT. Upserts update the columns
+// given by the first row.
+func (e sqlEngine) insert(table string, columns []string, rows []row, types map[string]kind) (polluter.Command, error) {
+	ins := Insert{
+		Table:     table,
+		Columns:   columns,
+		Upsert:    len(e.upsert) > 0,
+		Keys:      e.upsert,
+		Returning: e.returning,
+	}
+	if ins.Upsert && len(rows) > 0 {
+		ins.Update = rows[0].columns
+		if ins.Update == nil {
+			ins.Update = []string{}
+		}
+	}
+	args := make([]interface{}, 0)
+
+	for _, r := range rows {
+		values := make([]string, 0, len(columns))
+		for _, name := range columns {
+			switch v := r.values[name].(type) {
+			case nil:
+				values = append(values, "DEFAULT")
+			case expr:
+				values = append(values, string(v))
+			case jwalk.Value:
+				arg, err := coerce(types[name], v)
+				if err != nil {
+					return polluter.Command{}, errors.Wrap(err, name)
+				}
+				args = append(args, arg)
+				values = append(values, e.dialect.Placeholder(len(args)))
+			}
+		}
+		ins.Values = append(ins.Values, values)
+	}
+
+	q, err := e.dialect.Insert(ins)
+	if err != nil {
+		return polluter.Command{}, err
+	}
+
+	return polluter.Command{Q: q, Args: args, Table: table, Rows: len(rows)}, nil
+}
+
+// Truncate empties the tables with the
+// statements of the dialect. MySQL commits
+// the transaction given to the engine, if
+// any, before it truncates.
+func (e sqlEngine) Truncate(ctx context.Context, tables ...string) (err error) {
+	if len(tables) == 0 {
+		return nil
+	}
+
+	conn := e.db
+	if db, ok := e.db.(*sql.DB); ok {
+		c, err := db.Conn(ctx)
+		if err != nil {
+			return errors.Wrap(err, "conn")
+		}
+		defer c.Close()
+		conn = c
+	}
+
+	setup, body, teardown := e.dialect.Truncate(tables)
+	defer func() {
+		// The connection goes back to the pool, so its
+		// settings are restored even when ctx is done.
+		for _, stmt := range teardown {
+			if _, tErr := conn.ExecContext(context.Background(), stmt); tErr != nil && err == nil {
+				err = errors.Wrap(tErr, "truncate")
+			}
+		}
+	}()
+
+	for _, stmt := range append(setup, body...) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return errors.Wrap(err, "truncate")
+		}
+	}
+
+	return nil
+}
+
+// Revert deletes the rows of the tables of obj
+// in a transaction, the last ones first. It
+// fails with Upsert, which may have updated
+// rows that existed before.
+func (e sqlEngine) Revert(ctx context.Context, obj jwalk.ObjectWalker) error {
+	if len(e.upsert) > 0 {
+		return errors.New("revert is not supported with upsert")
+	}
+
+	cmds := make(polluter.Commands, 0)
+	if err := obj.Walk(func(table string, value interface{}) error {
+		v, ok := value.(jwalk.ObjectsWalker)
+		if !ok {
+			return nil
+		}
+		return v.Walk(func(row jwalk.ObjectWalker) error {
+			c, err := e.deleteRow(table, row)
+			if err != nil {
+				return err
+			}
+			cmds = append(polluter.Commands{c}, cmds...)
+			return nil
+		})
+	}); err != nil {
+		return err
+	}
+
+	e.returned = nil
+	return e.ExecContext(ctx, cmds)
+}
+
+// deleteRow deletes a row by its id, or by
+// all its scalar columns without one.
+func (e sqlEngine) deleteRow(table string, row jwalk.ObjectWalker) (polluter.Command, error) {
+	fields := make(polluter.Record, 0)
+	if err := row.Walk(func(field string, value interface{}) error {
+		if v, ok := value.(jwalk.Value); ok {
+			fields = append(fields, polluter.Field{Name: field, Value: v.Interface()})
+		}
+		return nil
+	}); err != nil {
+		return polluter.Command{}, err
+	}
+	if id, ok := fields.Get("id"); ok {
+		fields = polluter.Record{{Name: "id", Value: id}}
+	}
+
+	conds := make([]string, 0, len(fields))
+	args := make([]interface{}, 0, len(fields))
+	for _, f := range fields {
+		switch f.Value.(type) {
+		case nil:
+			conds = append(conds, e.dialect.Quote(f.Name)+" IS NULL")
+		case []interface{}, map[string]interface{}:
+			// Arrays and objects are not compared.
+		default:
+			args = append(args, f.Value)
+			conds = append(conds, fmt.Sprintf("%s = %s", e.dialect.Quote(f.Name), e.dialect.Placeholder(len(args))))
+		}
+	}
+	if len(conds) == 0 {
+		return polluter.Command{}, errors.Errorf("cannot identify a row of %s", table)
+	}
+
+	return polluter.Command{
+		Q:     fmt.Sprintf("DELETE FROM %s WHERE %s;", e.dialect.Quote(table), strings.Join(conds, " AND ")),
+		Args:  args,
+		Table: table,
+		Rows:  1,
+	}, nil
+}
+
+// Fetch reads back the rows of the tables
+// of obj, restricted to their columns.
+func (e sqlEngine) Fetch(ctx context.Context, obj jwalk.ObjectWalker) (polluter.Record, error) {
+	doc := make(polluter.Record, 0)
+
+	if err := obj.Walk(func(table string, value interface{}) error {
+		v, ok := value.(jwalk.ObjectsWalker)
+		if !ok {
+			return nil
+		}
+
+		columns, err := polluter.Columns(v)
+		if err != nil {
+			return err
+		}
+
+		rows, err := e.db.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s;", quoteAll(e.dialect, columns), e.dialect.Quote(table)))
+		if err != nil {
+			return errors.Wrapf(err, "select %s", table)
+		}
+
+		records, err := polluter.ScanRecords(rows)
+		if err != nil {
+			return errors.Wrapf(err, "select %s", table)
+		}
+
+		doc = append(doc, polluter.Field{Name: table, Value: records})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Dump reads the selected tables, the
+// tables they reference coming first.
+func (e sqlEngine) Dump(ctx context.Context, sel ...polluter.Selection) (polluter.Record, error) {
+	deps, err := e.dependencies(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "foreign keys")
+	}
+
+	tables := make([]string, 0, len(sel))
+	selections := make(map[string]polluter.Selection, len(sel))
+	for _, s := range sel {
+		tables = append(tables, s.Name)
+		selections[s.Name] = s
+	}
+
+	doc := make(polluter.Record, 0, len(sel))
+	for _, table := range polluter.SortByDependencies(tables, deps) {
+		s := selections[table]
+		q := fmt.Sprintf("SELECT * FROM %s", e.dialect.Quote(table))
+		if s.Where != "" {
+			q = q + " WHERE " + s.Where
+		}
+		if s.Limit > 0 {
+			q = q + e.dialect.Limit(s.Limit)
+		}
+
+		rows, err := e.db.QueryContext(ctx, q+";", s.Args...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "select %s", table)
+		}
+
+		records, err := polluter.ScanRecords(rows)
+		if err != nil {
+			return nil, errors.Wrapf(err, "select %s", table)
+		}
+
+		doc = append(doc, polluter.Field{Name: table, Value: records})
+	}
+
+	return doc, nil
+}
+
+// Describe reads the columns of the
+// tables of the current schema.
+func (e sqlEngine) Describe(ctx context.Context) (map[string][]polluter.Column, error) {
+	columns, err := readColumns(ctx, e.db, e.dialect)
+	if err != nil {
+		return nil, errors.Wrap(err, "columns")
+	}
+
+	tables := make(map[string][]polluter.Column)
+	for _, c := range columns {
+		tables[c.table] = append(tables[c.table], c.Column)
+	}
+	return tables, nil
+}
+
+// dependencies returns the tables
+// each table references.
+func (e sqlEngine) dependencies(ctx context.Context) (map[string][]string, error) {
+	rows, err := e.db.QueryContext(ctx, e.dialect.ForeignKeys())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deps := make(map[string][]string)
+	for rows.Next() {
+		var table, referenced string
+		if err := rows.Scan(&table, &referenced); err != nil {
+			return nil, err
+		}
+		deps[table] = append(deps[table], referenced)
+	}
+
+	return deps, rows.Err()
+}
+
+// SQLEngine option enables an engine for
+// any database/sql database, given its
+// dialect. The engine runs on a database,
+// a connection or a transaction.
+func SQLEngine(db DB, dialect Dialect, opts ...Option) polluter.DbEngine {
+	e := sqlEngine{db: db, dialect: dialect}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return e
+}
+
+// Upsert option updates the rows which conflict
+// on the key columns, id by default, instead of
+// failing. MySQL ignores the keys and updates
+// rows conflicting on any unique key.
+func Upsert(keys ...string) Option {
+	if len(keys) == 0 {
+		keys = []string{"id"}
+	}
+	return func(e *sqlEngine) {
+		e.upsert = keys
+	}
+}
+
+// Returning option reads back the columns of
+// inserted rows, like generated ids, and
+// passes them to fn. MySQL does not support
+// it.
+func Returning(fn func(polluter.Record), columns ...string) Option {
+	return func(e *sqlEngine) {
+		e.returned = fn
+		e.returning = columns
+	}
+}
+
+// Coerce option reads the types of the columns
+// before the first build, again until it has
+// succeeded, and converts values to suit them:
+// strings to times for timestamps and dates,
+// numbers to strings for decimals, numbers and
+// strings to booleans, booleans to integers,
+// base64 strings to bytes for binary columns
+// and UUIDs to their canonical form.
+func Coerce() Option {
+	return func(e *sqlEngine) {
+		e.schema = new(schema)
+	}
+}
+
+// Normalize option inserts all the rows of a
+// table with the same columns, in the order
+// they first appear, filling the ones a row
+// omits with DEFAULT, so that they are
+// inserted by multi-row INSERTs. Upserts do
+// not update the columns filled with DEFAULT.
+func Normalize() Option {
+	return func(e *sqlEngine) {
+		e.normalize = true
+	}
+}
+
+// Log option logs the commands executed
+// by the engine, see polluter.Log.
+func Log(l polluter.Logger, opts ...polluter.LogOption) Option {
+	return func(e *sqlEngine) {
+		e.log = polluter.NewCommandLogger(l, opts...)
+	}
+}
@@ -0,0 +1,389 @@
+package sqldb_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/database/sqldb"
+	"github.com/quen2404/polluter/parser/json"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_sqlEngine_build(t *testing.T) {
+	input := []byte(`{"users":[{"id":1,"name":"Roman","role":{"id":1}}],"count":1}`)
+
+	tests := []struct {
+		name    string
+		dialect sqldb.Dialect
+		opts    []sqldb.Option
+		expect  polluter.Commands
+		wantErr bool
+	}{
+		{
+			name:    "sqlite",
+			dialect: sqldb.SQLite,
+			expect: polluter.Commands{
+				{Q: `INSERT INTO "users" ("id", "name") VALUES (?, ?);`, Args: []interface{}{float64(1), "Roman"}, Table: "users", Rows: 1},
+			},
+		},
+		{
+			name:    "sqlserver upsert on name",
+			dialect: sqldb.SQLServer,
+			opts:    []sqldb.Option{sqldb.Upsert("name")},
+			expect: polluter.Commands{
+				{
+					Table: "users",
+					Rows:  1,
+					Q: "MERGE INTO [users] AS target USING (VALUES (@p1, @p2)) AS source ([id], [name]) ON target.[name] = source.[name]" +
+						" WHEN MATCHED THEN UPDATE SET target.[id] = source.[id]" +
+						" WHEN NOT MATCHED THEN INSERT ([id], [name]) VALUES (source.[id], source.[name]);",
+					Args: []interface{}{float64(1), "Roman"},
+				},
+			},
+		},
+		{
+			name:    "postgres returning",
+			dialect: sqldb.Postgres,
+			opts:    []sqldb.Option{sqldb.Returning(func(polluter.Record) {}, "id")},
+			expect: polluter.Commands{
+				{Q: `INSERT INTO "users" ("id", "name") VALUES ($1, $2) RETURNING "id";`, Args: []interface{}{float64(1), "Roman"}, Table: "users", Rows: 1},
+			},
+		},
+		{
+			name:    "mysql returning",
+			dialect: sqldb.MySQL,
+			opts:    []sqldb.Option{sqldb.Returning(func(polluter.Record) {}, "id")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			obj, err := json.JSONParser().Parse(bytes.NewReader(input))
+			if err != nil {
+				assert.Nil(t, err)
+				return
+			}
+
+			got, err := sqldb.SQLEngine(nil, tt.dialect, tt.opts...).Build(obj)
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expect, got)
+		})
+	}
+}
+
+func Test_sqlEngine_buildRaw(t *testing.T) {
+	input := []byte(`{"users":[{"id":1,"name":null,"created_at":{"$sql":"now()"},"status":{"$default":true}}]}`)
+
+	tests := []struct {
+		name    string
+		dialect sqldb.Dialect
+		expect  polluter.Commands
+	}{
+		{
+			name:    "postgres",
+			dialect: sqldb.Postgres,
+			expect: polluter.Commands{
+				{
+					Table: "users",
+					Rows:  1,
+					Q:     `INSERT INTO "users" ("id", "name", "created_at", "status") VALUES ($1, $2, now(), DEFAULT);`,
+					Args:  []interface{}{float64(1), nil},
+				},
+			},
+		},
+		{
+			name:    "mysql",
+			dialect: sqldb.MySQL,
+			expect: polluter.Commands{
+				{
+					Table: "users",
+					Rows:  1,
+					Q:     "INSERT INTO `users` (`id`, `name`, `created_at`, `status`) VALUES (?, ?, now(), DEFAULT);",
+					Args:  []interface{}{float64(1), nil},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			obj, err := json.JSONParser().Parse(bytes.NewReader(input))
+			if err != nil {
+				assert.Nil(t, err)
+				return
+			}
+
+			got, err := sqldb.SQLEngine(nil, tt.dialect).Build(obj)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expect, got)
+		})
+	}
+}
+
+func Test_sqlEngine_buildNormalize(t *testing.T) {
+	input := []byte(`{"users":[{"id":1},{"name":"Dmitry","id":2,"created_at":{"$sql":"now()"}},{"id":3,"name":"Anna","created_at":null}]}`)
+
+	tests := []struct {
+		name    string
+		dialect sqldb.Dialect
+		opts    []sqldb.Option
+		expect  polluter.Commands
+	}{
+		{
+			name:    "multi-row insert",
+			dialect: sqldb.Postgres,
+			expect: polluter.Commands{
+				{
+					Table: "users",
+					Rows:  3,
+					Q:     `INSERT INTO "users" ("id", "name", "created_at") VALUES ($1, DEFAULT, DEFAULT), ($2, $3, now()), ($4, $5, $6);`,
+					Args:  []interface{}{float64(1), float64(2), "Dmitry", float64(3), "Anna", nil},
+				},
+			},
+		},
+		{
+			name:    "upsert leaves defaults out",
+			dialect: sqldb.Postgres,
+			opts:    []sqldb.Option{sqldb.Upsert()},
+			expect: polluter.Commands{
+				{
+					Table: "users",
+					Rows:  1,
+					Q:     `INSERT INTO "users" ("id", "name", "created_at") VALUES ($1, DEFAULT, DEFAULT) ON CONFLICT ("id") DO NOTHING;`,
+					Args:  []interface{}{float64(1)},
+				},
+				{
+					Table: "users",
+					Rows:  2,
+					Q: `INSERT INTO "users" ("id", "name", "created_at") VALUES ($1, $2, now()), ($3, $4, $5)` +
+						` ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name", "created_at" = EXCLUDED."created_at";`,
+					Args: []interface{}{float64(2), "Dmitry", float64(3), "Anna", nil},
+				},
+			},
+		},
+		{
+			name:    "mysql upsert leaves defaults out",
+			dialect: sqldb.MySQL,
+			opts:    []sqldb.Option{sqldb.Upsert()},
+			expect: polluter.Commands{
+				{
+					Table: "users",
+					Rows:  1,
+					Q:     "INSERT INTO `users` (`id`, `name`, `created_at`) VALUES (?, DEFAULT, DEFAULT) ON DUPLICATE KEY UPDATE `id` = VALUES(`id`);",
+					Args:  []interface{}{float64(1)},
+				},
+				{
+					Table: "users",
+					Rows:  2,
+					Q: "INSERT INTO `users` (`id`, `name`, `created_at`) VALUES (?, ?, now()), (?, ?, ?)" +
+						" ON DUPLICATE KEY UPDATE `name` = VALUES(`name`), `id` = VALUES(`id`), `created_at` = VALUES(`created_at`);",
+					Args: []interface{}{float64(2), "Dmitry", float64(3), "Anna", nil},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			obj, err := json.JSONParser().Parse(bytes.NewReader(input))
+			if err != nil {
+				assert.Nil(t, err)
+				return
+			}
+
+			opts := append([]sqldb.Option{sqldb.Normalize()}, tt.opts...)
+			got, err := sqldb.SQLEngine(nil, tt.dialect, opts...).Build(obj)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expect, got)
+		})
+	}
+}
+
+func Test_sqlEngine_buildNormalizeLimit(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString(`{"users":[`)
+	for i := 0; i < 1500; i++ {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(`{"id":1}`)
+	}
+	b.WriteString(`]}`)
+
+	obj, err := json.JSONParser().Parse(&b)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	got, err := sqldb.SQLEngine(nil, sqldb.SQLite, sqldb.Normalize()).Build(obj)
+	assert.Nil(t, err)
+	if assert.Len(t, got, 2) {
+		assert.Len(t, got[0].Args, 999)
+		assert.Len(t, got[1].Args, 501)
+	}
+}
+
+// txDB records the statements run on
+// a transaction given to the engine.
+type txDB struct {
+	queries []string
+}
+
+func (db *txDB) ExecContext(_ context.Context, q string, _ ...interface{}) (sql.Result, error) {
+	db.queries = append(db.queries, q)
+	if strings.Contains(q, "broken") {
+		return nil, errors.New("broken")
+	}
+	return nil, nil
+}
+
+func (db *txDB) QueryContext(_ context.Context, q string, _ ...interface{}) (*sql.Rows, error) {
+	db.queries = append(db.queries, q)
+	return nil, errors.New("not supported")
+}
+
+func Test_sqlEngine_savepoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmds    polluter.Commands
+		expect  []string
+		wantErr bool
+	}{
+		{
+			name:   "released",
+			cmds:   polluter.Commands{{Q: "INSERT"}},
+			expect: []string{`SAVEPOINT "polluter";`, "INSERT", `RELEASE SAVEPOINT "polluter";`},
+		},
+		{
+			name:    "rolled back",
+			cmds:    polluter.Commands{{Q: "INSERT"}, {Q: "broken"}, {Q: "INSERT"}},
+			expect:  []string{`SAVEPOINT "polluter";`, "INSERT", "broken", `ROLLBACK TO SAVEPOINT "polluter";`},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := new(txDB)
+			err := sqldb.SQLEngine(db, sqldb.Postgres).Exec(tt.cmds)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.expect, db.queries)
+		})
+	}
+}
+
+func Test_sqlEngine_revert(t *testing.T) {
+	input := []byte(`{"roles":[{"id":1}],"users":[{"id":1,"name":"Roman"},{"name":"Dmitry"}]}`)
+
+	tests := []struct {
+		name    string
+		opts    []sqldb.Option
+		expect  []string
+		wantErr bool
+	}{
+		{
+			name: "last rows first",
+			expect: []string{
+				`SAVEPOINT "polluter";`,
+				`DELETE FROM "users" WHERE "name" = $1;`,
+				`DELETE FROM "users" WHERE "id" = $1;`,
+				`DELETE FROM "roles" WHERE "id" = $1;`,
+				`RELEASE SAVEPOINT "polluter";`,
+			},
+		},
+		{
+			name:    "upsert",
+			opts:    []sqldb.Option{sqldb.Upsert()},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			obj, err := json.JSONParser().Parse(bytes.NewReader(input))
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			db := new(txDB)
+			e := sqldb.SQLEngine(db, sqldb.Postgres, tt.opts...).(polluter.Reverter)
+			err = e.Revert(context.Background(), obj)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.expect, db.queries)
+		})
+	}
+}
+
+func Test_sqlEngine_truncate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tables  []string
+		expect  []string
+		wantErr bool
+	}{
+		{
+			name:   "truncated",
+			tables: []string{"users", "roles"},
+			expect: []string{"SET FOREIGN_KEY_CHECKS = 0;", "TRUNCATE TABLE `users`;", "TRUNCATE TABLE `roles`;", "SET FOREIGN_KEY_CHECKS = 1;"},
+		},
+		{
+			name:    "teardown after failure",
+			tables:  []string{"broken", "roles"},
+			expect:  []string{"SET FOREIGN_KEY_CHECKS = 0;", "TRUNCATE TABLE `broken`;", "SET FOREIGN_KEY_CHECKS = 1;"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db := new(txDB)
+			e := sqldb.SQLEngine(db, sqldb.MySQL).(polluter.Truncater)
+			err := e.Truncate(context.Background(), tt.tables...)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.expect, db.queries)
+		})
+	}
+}
+
+func Test_sqlEngine_buildCoerceRetry(t *testing.T) {
+	obj, err := json.JSONParser().Parse(bytes.NewReader([]byte(`{"users":[{"id":1}]}`)))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	db := new(txDB)
+	e := sqldb.SQLEngine(db, sqldb.Postgres, sqldb.Coerce()).(polluter.ContextBuilder)
+	for i := 0; i < 2; i++ {
+		_, err := e.BuildContext(context.Background(), obj)
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, []string{sqldb.Postgres.Columns(), sqldb.Postgres.Columns()}, db.queries)
+}
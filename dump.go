@@ -0,0 +1,97 @@
+package polluter
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/quen2404/polluter/parser"
+)
+
+type (
+	// Selection picks what a Dumper reads.
+	Selection struct {
+		// Name is a table or a collection,
+		// or a key pattern for Redis.
+		Name string
+		// Where filters rows with an SQL
+		// condition using Args as parameters,
+		// or an extended JSON filter for Mongo.
+		Where string
+		Args  []interface{}
+		// Limit caps the number of rows,
+		// there is no limit when zero.
+		Limit int
+	}
+
+	// Dumper is implemented by engines which can
+	// export database contents as fixture records,
+	// ready to be fed back into Pollute. Tables
+	// come after the tables they reference.
+	Dumper interface {
+		Dump(context.Context, ...Selection) (Record, error)
+	}
+)
+
+// Dump exports the selected contents of the
+// database to w, encoded by the parser of the
+// polluter which must implement parser.Encoder.
+func (p *Polluter) Dump(ctx context.Context, w io.Writer, sel ...Selection) error {
+	dumper, ok := p.DbEngine.(Dumper)
+	if !ok {
+		return errors.New("engine does not support dump")
+	}
+	enc, ok := p.Parser.(parser.Encoder)
+	if !ok {
+		return errors.New("parser does not support encoding")
+	}
+
+	doc, err := dumper.Dump(ctx, sel...)
+	if err != nil {
+		return errors.Wrap(err, "dump failed")
+	}
+
+	obj, err := doc.Walker()
+	if err != nil {
+		return errors.Wrap(err, "convert records")
+	}
+
+	return errors.Wrap(enc.Encode(w, obj), "encode failed")
+}
+
+// SortByDependencies orders tables so that each
+// one comes after the tables it depends on, as
+// given by deps. The order of tables is kept
+// otherwise. Tables in a cycle keep their order.
+func SortByDependencies(tables []string, deps map[string][]string) []string {
+	sorted := make([]string, 0, len(tables))
+	done := make(map[string]bool, len(tables))
+	visiting := make(map[string]bool)
+
+	selected := make(map[string]bool, len(tables))
+	for _, t := range tables {
+		selected[t] = true
+	}
+
+	var visit func(string)
+	visit = func(t string) {
+		if done[t] || visiting[t] {
+			return
+		}
+		visiting[t] = true
+		for _, d := range deps[t] {
+			if selected[d] && d != t {
+				visit(d)
+			}
+		}
+		visiting[t] = false
+		done[t] = true
+		sorted = append(sorted, t)
+	}
+
+	for _, t := range tables {
+		visit(t)
+	}
+
+	return sorted
+}
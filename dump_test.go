@@ -0,0 +1,123 @@
+package polluter_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	pjson "github.com/quen2404/polluter/parser/json"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+type dumperEngine struct {
+	fakeEngine
+	state polluter.Record
+}
+
+func (e dumperEngine) Dump(_ context.Context, sel ...polluter.Selection) (polluter.Record, error) {
+	doc := make(polluter.Record, 0, len(sel))
+	for _, s := range sel {
+		if v, ok := e.state.Get(s.Name); ok {
+			doc = append(doc, polluter.Field{Name: s.Name, Value: v})
+		}
+	}
+	return doc, nil
+}
+
+func TestPolluterDump(t *testing.T) {
+	state := polluter.Record{
+		{Name: "roles", Value: []polluter.Record{
+			{{Name: "id", Value: json.Number("1")}, {Name: "name", Value: "User"}},
+		}},
+		{Name: "users", Value: []polluter.Record{
+			{{Name: "id", Value: json.Number("1")}, {Name: "name", Value: "Roman"}, {Name: "role_id", Value: json.Number("1")}},
+		}},
+	}
+	sel := []polluter.Selection{{Name: "roles"}, {Name: "users"}}
+
+	t.Run("yaml round trip", func(t *testing.T) {
+		t.Parallel()
+
+		p := polluter.New(dumperEngine{state: state}, yaml.YAMLParser())
+		buf := new(bytes.Buffer)
+		if err := p.Dump(context.Background(), buf, sel...); err != nil {
+			assert.Nil(t, err)
+			return
+		}
+
+		assert.Equal(t, "roles:\n- id: 1\n  name: User\nusers:\n- id: 1\n  name: Roman\n  role_id: 1\n", buf.String())
+
+		checker := polluter.New(checkerEngine{state: state}, yaml.YAMLParser())
+		assert.Nil(t, checker.Verify(context.Background(), strings.NewReader(buf.String())))
+	})
+
+	t.Run("json", func(t *testing.T) {
+		t.Parallel()
+
+		p := polluter.New(dumperEngine{state: state}, pjson.JSONParser())
+		buf := new(bytes.Buffer)
+		assert.Nil(t, p.Dump(context.Background(), buf, polluter.Selection{Name: "roles"}))
+		assert.Equal(t, "{\n  \"roles\": [\n    {\n      \"id\": 1,\n      \"name\": \"User\"\n    }\n  ]\n}\n", buf.String())
+	})
+
+	t.Run("engine without dump", func(t *testing.T) {
+		t.Parallel()
+
+		p := polluter.New(fakeEngine{}, yaml.YAMLParser())
+		assert.NotNil(t, p.Dump(context.Background(), new(bytes.Buffer), sel...))
+	})
+}
+
+func TestSortByDependencies(t *testing.T) {
+	tests := []struct {
+		name   string
+		tables []string
+		deps   map[string][]string
+		expect []string
+	}{
+		{
+			name:   "no dependencies keeps order",
+			tables: []string{"b", "a", "c"},
+			expect: []string{"b", "a", "c"},
+		},
+		{
+			name:   "referenced tables first",
+			tables: []string{"users", "comments", "roles"},
+			deps: map[string][]string{
+				"users":    {"roles"},
+				"comments": {"users"},
+			},
+			expect: []string{"roles", "users", "comments"},
+		},
+		{
+			name:   "unselected and self references ignored",
+			tables: []string{"users", "roles"},
+			deps: map[string][]string{
+				"users": {"users", "accounts"},
+			},
+			expect: []string{"users", "roles"},
+		},
+		{
+			name:   "cycle",
+			tables: []string{"a", "b"},
+			deps: map[string][]string{
+				"a": {"b"},
+				"b": {"a"},
+			},
+			expect: []string{"b", "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.expect, polluter.SortByDependencies(tt.tables, tt.deps))
+		})
+	}
+}
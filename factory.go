@@ -0,0 +1,211 @@
+package polluter
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// traitsField is the field of a fixture record
+// listing the traits of its factory to apply.
+const traitsField = "$traits"
+
+// Factory describes how records of a table are
+// made: Defaults holds the default columns and
+// Traits named sets of columns which override
+// them. A record then only states differences:
+//
+//	users:
+//	- name: Roman
+//	  $traits: [admin]
+type Factory struct {
+	Defaults Record
+	Traits   map[string]Record
+}
+
+// Factories option registers factories
+// by the name of their table.
+func Factories(factories map[string]Factory) Option {
+	return func(p *Polluter) {
+		for table, f := range factories {
+			p.Define(table, f)
+		}
+	}
+}
+
+// Define registers the factory of a table,
+// replacing the previous one if any.
+func (p *Polluter) Define(table string, f Factory) {
+	if p.factories == nil {
+		p.factories = make(map[string]Factory)
+	}
+	p.factories[table] = f
+}
+
+// LoadFactories reads factories with the parser
+// of the polluter. Every table has its defaults
+// and traits:
+//
+//	users:
+//	  defaults:
+//	    name: John
+//	    role: user
+//	  traits:
+//	    admin:
+//	      role: admin
+func (p *Polluter) LoadFactories(r io.Reader) error {
+	obj, err := p.Parser.Parse(r)
+	if err != nil {
+		return errors.Wrap(err, "parse failed")
+	}
+
+	doc, err := RecordOf(obj)
+	if err != nil {
+		return errors.Wrap(err, "read factories")
+	}
+
+	for _, table := range doc {
+		def, ok := table.Value.(Record)
+		if !ok {
+			return errors.Errorf("factory %s is not an object", table.Name)
+		}
+
+		f := Factory{Traits: make(map[string]Record)}
+		for _, field := range def {
+			switch field.Name {
+			case "defaults":
+				if f.Defaults, ok = field.Value.(Record); !ok {
+					return errors.Errorf("defaults of factory %s are not an object", table.Name)
+				}
+			case "traits":
+				traits, ok := field.Value.(Record)
+				if !ok {
+					return errors.Errorf("traits of factory %s are not an object", table.Name)
+				}
+				for _, trait := range traits {
+					if f.Traits[trait.Name], ok = trait.Value.(Record); !ok {
+						return errors.Errorf("trait %s of factory %s is not an object", trait.Name, table.Name)
+					}
+				}
+			default:
+				return errors.Errorf("unknown field %s of factory %s", field.Name, table.Name)
+			}
+		}
+
+		p.Define(table.Name, f)
+	}
+
+	return nil
+}
+
+// applyFactories merges defaults and traits
+// into the records of tables with a factory.
+func (p *Polluter) applyFactories(doc Record) (Record, error) {
+	res := make(Record, 0, len(doc))
+	for _, table := range doc {
+		f, ok := p.factories[table.Name]
+		rows, isRows := table.Value.([]Record)
+		if !ok || !isRows {
+			res = append(res, table)
+			continue
+		}
+
+		merged := make([]Record, 0, len(rows))
+		for i, row := range rows {
+			r, err := f.make(row)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s[%d]", table.Name, i)
+			}
+			merged = append(merged, r)
+		}
+		res = append(res, Field{Name: table.Name, Value: merged})
+	}
+
+	return res, nil
+}
+
+func (f Factory) make(row Record) (Record, error) {
+	r := make(Record, 0, len(f.Defaults)+len(row))
+	r = append(r, f.Defaults...)
+
+	if traits, ok := row.Get(traitsField); ok {
+		names, err := traitNames(traits)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			trait, ok := f.Traits[name]
+			if !ok {
+				return nil, errors.Errorf("unknown trait %s", name)
+			}
+			for _, field := range trait {
+				r = r.Set(field.Name, field.Value)
+			}
+		}
+	}
+
+	for _, field := range row.Delete(traitsField) {
+		r = r.Set(field.Name, field.Value)
+	}
+
+	return r, nil
+}
+
+func traitNames(v interface{}) ([]string, error) {
+	switch v := v.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		names := make([]string, 0, len(v))
+		for _, name := range v {
+			s, ok := name.(string)
+			if !ok {
+				return nil, errors.Errorf("trait %v is not a string", name)
+			}
+			names = append(names, s)
+		}
+		return names, nil
+	default:
+		return nil, errors.Errorf("%s must be a trait or a list of traits", traitsField)
+	}
+}
+
+// Create inserts a single record into table made
+// by its factory, if any, with the given traits
+// and the overrides on top.
+func (p *Polluter) Create(ctx context.Context, table string, overrides map[string]interface{}, traits ...string) (err error) {
+	ctx, _, end := p.span(ctx, "polluter.Create", attribute.String("polluter.table", table))
+	defer end(&err)
+
+	if _, ok := p.factories[table]; !ok && len(traits) > 0 {
+		return errors.Errorf("no factory for %s", table)
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	row := make(Record, 0, len(overrides)+1)
+	if len(traits) > 0 {
+		list := make([]interface{}, len(traits))
+		for i, t := range traits {
+			list[i] = t
+		}
+		row = append(row, Field{Name: traitsField, Value: list})
+	}
+	for _, name := range names {
+		row = append(row, Field{Name: name, Value: overrides[name]})
+	}
+
+	obj, err := Record{{Name: table, Value: []Record{row}}}.Walker()
+	if err != nil {
+		return errors.Wrap(err, "convert record")
+	}
+
+	return p.pollute(ctx, obj)
+}
@@ -0,0 +1,112 @@
+package polluter_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/romanyx/jwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+const factoriesInput = `users:
+  defaults:
+    name: John
+    role: user
+    active: true
+  traits:
+    admin:
+      role: admin
+    inactive:
+      active: false
+`
+
+func TestFactories(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		expect  string
+		wantErr bool
+	}{
+		{
+			name: "defaults and traits",
+			input: `roles:
+- name: admin
+users:
+- email: a@example.com
+- name: Roman
+  $traits: [admin, inactive]
+- role: guest
+  $traits: admin
+`,
+			expect: `{"roles":[{"name":"admin"}],"users":[` +
+				`{"name":"John","role":"user","active":true,"email":"a@example.com"},` +
+				`{"name":"Roman","role":"admin","active":false},` +
+				`{"name":"John","role":"guest","active":true}]}`,
+		},
+		{
+			name:    "unknown trait",
+			input:   "users:\n- $traits: [owner]\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []byte
+			engine := buildFunc(func(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+				var err error
+				got, err = obj.MarshalJSON()
+				return nil, err
+			})
+
+			p := polluter.New(engine, yaml.YAMLParser())
+			if err := p.LoadFactories(strings.NewReader(factoriesInput)); err != nil {
+				assert.Nil(t, err)
+				return
+			}
+
+			err := p.Pollute(strings.NewReader(tt.input))
+
+			if tt.wantErr && err == nil {
+				assert.NotNil(t, err)
+				return
+			}
+
+			if !tt.wantErr {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expect, string(got))
+			}
+		})
+	}
+}
+
+func TestPolluterCreate(t *testing.T) {
+	var got []byte
+	engine := buildFunc(func(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+		var err error
+		got, err = obj.MarshalJSON()
+		return nil, err
+	})
+
+	p := polluter.New(engine, yaml.YAMLParser(), polluter.Factories(map[string]polluter.Factory{
+		"users": {
+			Defaults: polluter.Record{{Name: "name", Value: "John"}, {Name: "role", Value: "user"}},
+			Traits: map[string]polluter.Record{
+				"admin": {{Name: "role", Value: "admin"}},
+			},
+		},
+	}))
+
+	err := p.Create(context.Background(), "users", map[string]interface{}{"name": "Roman", "age": 30}, "admin")
+	assert.Nil(t, err)
+	assert.Equal(t, `{"users":[{"name":"Roman","role":"admin","age":30}]}`, string(got))
+
+	err = p.Create(context.Background(), "roles", nil, "admin")
+	assert.NotNil(t, err)
+}
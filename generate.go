@@ -0,0 +1,221 @@
+package polluter
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// generators evaluates generator expressions
+// such as $uuid() found in fixture values.
+type generators struct {
+	mu      sync.Mutex
+	enabled bool
+	rand    *rand.Rand
+	seqs    map[string]int
+}
+
+// Generators option enables generator
+// expressions such as $uuid() in values.
+// Prefix an expression with $$ to keep
+// it as a literal: $$uuid() is $uuid().
+func Generators() Option {
+	return func(p *Polluter) {
+		p.generators.mu.Lock()
+		defer p.generators.mu.Unlock()
+		p.generators.enabled = true
+	}
+}
+
+// Seed option seeds the random generator
+// behind $fake and $uuid, so that values
+// are the same on every run.
+func Seed(seed int64) Option {
+	return func(p *Polluter) {
+		p.generators.mu.Lock()
+		defer p.generators.mu.Unlock()
+		p.generators.rand = rand.New(rand.NewSource(seed))
+	}
+}
+
+// on reports whether generator
+// expressions are evaluated.
+func (g *generators) on() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.enabled
+}
+
+var generatorExpr = regexp.MustCompile(`^(\$?)\$(fake|uuid|now|seq)\((.*)\)$`)
+
+// generate replaces values which are generator
+// expressions of the whole value:
+//
+//	$fake(email)      // fake data of a kind
+//	$fake(int, 1, 10) // random int in [1, 10]
+//	$uuid()           // random UUID v4
+//	$now(-24h)        // current time plus a duration,
+//	                  // layout may follow the duration
+//	$seq(users)       // 1, 2, ... per name, the table by default
+//
+// Sequences start over on every call.
+// Kinds of $fake are name, first_name, last_name,
+// username, email, phone, company, city, country,
+// street, word, sentence, bool and int.
+func (g *generators) generate(doc Record) (Record, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.enabled {
+		return doc, nil
+	}
+	if g.rand == nil {
+		g.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	g.seqs = make(map[string]int)
+
+	res := make(Record, 0, len(doc))
+	for _, f := range doc {
+		table := f.Name
+		value, err := mapStrings(f.Value, func(s string) (interface{}, error) {
+			m := generatorExpr.FindStringSubmatch(s)
+			if m == nil {
+				return s, nil
+			}
+			if m[1] != "" {
+				return s[1:], nil
+			}
+			return g.eval(table, m[2], splitArgs(m[3]))
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, f.Name)
+		}
+		res = append(res, Field{Name: f.Name, Value: value})
+	}
+
+	return res, nil
+}
+
+func splitArgs(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	args := strings.Split(s, ",")
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
+	}
+	return args
+}
+
+func (g *generators) eval(table, name string, args []string) (interface{}, error) {
+	switch name {
+	case "fake":
+		if len(args) == 0 {
+			return nil, errors.New("$fake needs a kind")
+		}
+		return g.fake(args[0], args[1:])
+	case "uuid":
+		b := make([]byte, 16)
+		g.rand.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+	case "now":
+		t := time.Now().UTC()
+		if len(args) > 0 && args[0] != "" {
+			d, err := time.ParseDuration(args[0])
+			if err != nil {
+				return nil, errors.Wrap(err, "$now")
+			}
+			t = t.Add(d)
+		}
+		layout := "2006-01-02 15:04:05"
+		if len(args) > 1 {
+			layout = strings.Join(args[1:], ", ")
+		}
+		return t.Format(layout), nil
+	case "seq":
+		key := table
+		if len(args) > 0 {
+			key = args[0]
+		}
+		g.seqs[key]++
+		return g.seqs[key], nil
+	default:
+		return nil, errors.Errorf("unknown generator $%s", name)
+	}
+}
+
+var (
+	firstNames = []string{"Roman", "Dmitry", "Anna", "Maria", "John", "Emma", "Liam", "Olivia", "Noah", "Sophia", "Lucas", "Mia"}
+	lastNames  = []string{"Smith", "Johnson", "Brown", "Garcia", "Miller", "Davis", "Martin", "Petrov", "Dubois", "Rossi", "Müller", "Silva"}
+	cities     = []string{"Paris", "Berlin", "London", "Moscow", "Madrid", "Rome", "Lisbon", "Warsaw", "Prague", "Vienna"}
+	countries  = []string{"France", "Germany", "United Kingdom", "Russia", "Spain", "Italy", "Portugal", "Poland", "Czechia", "Austria"}
+	companies  = []string{"Acme", "Globex", "Initech", "Umbrella", "Hooli", "Stark Industries", "Wayne Enterprises", "Soylent"}
+	streets    = []string{"Main Street", "High Street", "Park Avenue", "Oak Lane", "Station Road", "Church Street"}
+	words      = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do", "eiusmod", "tempor"}
+	domains    = []string{"example.com", "example.org", "example.net"}
+)
+
+func (g *generators) pick(list []string) string {
+	return list[g.rand.Intn(len(list))]
+}
+
+func (g *generators) fake(kind string, args []string) (interface{}, error) {
+	switch kind {
+	case "first_name":
+		return g.pick(firstNames), nil
+	case "last_name":
+		return g.pick(lastNames), nil
+	case "name":
+		return g.pick(firstNames) + " " + g.pick(lastNames), nil
+	case "username":
+		return strings.ToLower(g.pick(firstNames)) + strconv.Itoa(g.rand.Intn(10000)), nil
+	case "email":
+		return fmt.Sprintf("%s.%s%d@%s", strings.ToLower(g.pick(firstNames)), strings.ToLower(g.pick(lastNames)), g.rand.Intn(1000), g.pick(domains)), nil
+	case "phone":
+		return fmt.Sprintf("+1-%03d-%03d-%04d", 200+g.rand.Intn(800), g.rand.Intn(1000), g.rand.Intn(10000)), nil
+	case "company":
+		return g.pick(companies), nil
+	case "city":
+		return g.pick(cities), nil
+	case "country":
+		return g.pick(countries), nil
+	case "street":
+		return fmt.Sprintf("%d %s", 1+g.rand.Intn(200), g.pick(streets)), nil
+	case "word":
+		return g.pick(words), nil
+	case "sentence":
+		s := make([]string, 4+g.rand.Intn(6))
+		for i := range s {
+			s[i] = g.pick(words)
+		}
+		sentence := strings.Join(s, " ")
+		return strings.ToUpper(sentence[:1]) + sentence[1:] + ".", nil
+	case "bool":
+		return g.rand.Intn(2) == 1, nil
+	case "int":
+		min, max := 0, 1000
+		if len(args) == 2 {
+			var err error
+			if min, err = strconv.Atoi(args[0]); err != nil {
+				return nil, errors.Wrap(err, "$fake(int) min")
+			}
+			if max, err = strconv.Atoi(args[1]); err != nil {
+				return nil, errors.Wrap(err, "$fake(int) max")
+			}
+		}
+		if max < min {
+			return nil, errors.Errorf("$fake(int) max %d is less than min %d", max, min)
+		}
+		return min + g.rand.Intn(max-min+1), nil
+	default:
+		return nil, errors.Errorf("unknown $fake kind %s", kind)
+	}
+}
@@ -0,0 +1,140 @@
+package polluter_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/romanyx/jwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+const generatorsInput = `users:
+- id: $seq()
+  uuid: $uuid()
+  name: $fake(name)
+  email: $fake(email)
+  age: $fake(int, 18, 99)
+  created_at: $now(-24h)
+  kept: $unknown(x)
+  escaped: $$uuid()
+- id: $seq()
+  role_id: $seq(roles)
+  uuid: $uuid()
+`
+
+func pollutedRecords(t *testing.T, p *polluter.Polluter, input string) polluter.Record {
+	var doc polluter.Record
+	p.DbEngine = buildFunc(func(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+		var err error
+		doc, err = polluter.RecordOf(obj)
+		return nil, err
+	})
+
+	err := p.Pollute(strings.NewReader(input))
+	assert.Nil(t, err)
+	return doc
+}
+
+func TestGenerators(t *testing.T) {
+	doc := pollutedRecords(t, polluter.New(nil, yaml.YAMLParser(), polluter.Generators(), polluter.Seed(1)), generatorsInput)
+	users, _ := doc.Get("users")
+	rows := users.([]polluter.Record)
+
+	id, _ := rows[0].Get("id")
+	assert.Equal(t, json.Number("1"), id)
+	id, _ = rows[1].Get("id")
+	assert.Equal(t, json.Number("2"), id)
+	roleID, _ := rows[1].Get("role_id")
+	assert.Equal(t, json.Number("1"), roleID)
+
+	uuid, _ := rows[0].Get("uuid")
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), uuid)
+
+	email, _ := rows[0].Get("email")
+	assert.Regexp(t, regexp.MustCompile(`^\S+@example\.(com|org|net)$`), email)
+
+	created, _ := rows[0].Get("created_at")
+	ts, err := time.Parse("2006-01-02 15:04:05", created.(string))
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), ts, time.Minute)
+
+	kept, _ := rows[0].Get("kept")
+	assert.Equal(t, "$unknown(x)", kept)
+	escaped, _ := rows[0].Get("escaped")
+	assert.Equal(t, "$uuid()", escaped)
+
+	same := pollutedRecords(t, polluter.New(nil, yaml.YAMLParser(), polluter.Generators(), polluter.Seed(1)), generatorsInput)
+	sameUsers, _ := same.Get("users")
+	for _, field := range []string{"uuid", "name", "email", "age"} {
+		want, _ := rows[0].Get(field)
+		got, _ := sameUsers.([]polluter.Record)[0].Get(field)
+		assert.Equal(t, want, got, field)
+	}
+}
+
+func TestGeneratorsState(t *testing.T) {
+	t.Run("disabled by default", func(t *testing.T) {
+		t.Parallel()
+
+		doc := pollutedRecords(t, polluter.New(nil, yaml.YAMLParser()), generatorsInput)
+		users, _ := doc.Get("users")
+		uuid, _ := users.([]polluter.Record)[0].Get("uuid")
+		assert.Equal(t, "$uuid()", uuid)
+	})
+
+	t.Run("sequences restart", func(t *testing.T) {
+		t.Parallel()
+
+		p := polluter.New(nil, yaml.YAMLParser(), polluter.Generators())
+		for i := 0; i < 2; i++ {
+			doc := pollutedRecords(t, p, generatorsInput)
+			users, _ := doc.Get("users")
+			id, _ := users.([]polluter.Record)[0].Get("id")
+			assert.Equal(t, json.Number("1"), id)
+		}
+	})
+
+	t.Run("with interpolate", func(t *testing.T) {
+		t.Parallel()
+
+		p := polluter.New(nil, yaml.YAMLParser(), polluter.Generators(), polluter.Interpolate(map[string]interface{}{"MIN": 18}))
+		doc := pollutedRecords(t, p, "users:\n- escaped: $$uuid()\n  literal: $${MIN}\n  age: $fake(int, ${MIN}, ${MIN})\n")
+		users, _ := doc.Get("users")
+		row := users.([]polluter.Record)[0]
+
+		escaped, _ := row.Get("escaped")
+		assert.Equal(t, "$uuid()", escaped)
+		literal, _ := row.Get("literal")
+		assert.Equal(t, "${MIN}", literal)
+		age, _ := row.Get("age")
+		assert.Equal(t, json.Number("18"), age)
+	})
+}
+
+func TestGeneratorsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "unknown kind", input: "users:\n- name: $fake(nope)\n"},
+		{name: "missing kind", input: "users:\n- name: $fake()\n"},
+		{name: "invalid duration", input: "users:\n- at: $now(yesterday)\n"},
+		{name: "invalid range", input: "users:\n- age: $fake(int, 10, 1)\n"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := polluter.New(fakeEngine{}, yaml.YAMLParser(), polluter.Generators())
+			err := p.Pollute(strings.NewReader(tt.input))
+			assert.NotNil(t, err)
+		})
+	}
+}
@@ -1,9 +1,10 @@
 module github.com/quen2404/polluter
 
-go 1.15
+go 1.16
 
 require (
 	github.com/Azure/go-ansiterm v0.0.0-20170929234023-d6e3b3328b78 // indirect
+	github.com/BurntSushi/toml v1.2.1
 	github.com/DATA-DOG/go-txdb v0.1.0
 	github.com/Microsoft/go-winio v0.4.11 // indirect
 	github.com/Nvveen/Gotty v0.0.0-20120604004816-cd527374f1e5 // indirect
@@ -11,13 +12,12 @@ require (
 	github.com/containerd/continuity v0.0.0-20181027224239-bea7585dbfac // indirect
 	github.com/docker/go-connections v0.4.0 // indirect
 	github.com/docker/go-units v0.3.3 // indirect
-	github.com/fsnotify/fsnotify v1.4.7 // indirect
 	github.com/go-redis/redis v6.14.0+incompatible
 	github.com/go-sql-driver/mysql v1.5.0
-	github.com/golang/protobuf v1.2.0 // indirect
 	github.com/gotestyourself/gotestyourself v2.2.0+incompatible // indirect
 	github.com/hpcloud/tail v1.0.0 // indirect
-	github.com/lib/pq v1.0.0
+	github.com/jackc/pgx/v4 v4.14.1
+	github.com/lib/pq v1.10.2
 	github.com/onsi/ginkgo v1.6.0 // indirect
 	github.com/onsi/gomega v1.4.1 // indirect
 	github.com/opencontainers/go-digest v1.0.0-rc1 // indirect
@@ -26,10 +26,15 @@ require (
 	github.com/ory/dockertest v3.3.2+incompatible
 	github.com/pkg/errors v0.9.1
 	github.com/romanyx/jwalk v1.0.0
-	github.com/stretchr/testify v1.4.0
+	github.com/santhosh-tekuri/jsonschema/v5 v5.0.0
+	github.com/stretchr/testify v1.7.1
 	go.mongodb.org/mongo-driver v1.4.1
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/sdk v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
 	gopkg.in/fsnotify.v1 v1.4.7 // indirect
 	gopkg.in/tomb.v1 v1.0.0-20141024135613-dd632973f1e7 // indirect
 	gopkg.in/yaml.v2 v2.2.2
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 	gotest.tools v2.2.0+incompatible // indirect
 )
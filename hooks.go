@@ -0,0 +1,229 @@
+package polluter
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/romanyx/jwalk"
+)
+
+// ErrSkip is returned by hooks to skip
+// a row or a command without failing.
+var ErrSkip = errors.New("skip")
+
+type (
+	// Hook holds functions called around the
+	// steps of seeding. Any of them may be nil.
+	// Errors other than ErrSkip abort seeding.
+	Hook struct {
+		// BeforeParse may replace the input.
+		BeforeParse func(ctx context.Context, r io.Reader) (io.Reader, error)
+		// AfterParse may modify the parsed fixture.
+		AfterParse func(ctx context.Context, doc Record) (Record, error)
+		// BeforeBuild is called for each row of
+		// a table and for each object value of a
+		// key, named by table. It may modify the
+		// row or skip it with ErrSkip.
+		BeforeBuild func(ctx context.Context, table string, row Record) (Record, error)
+		// AfterBuild may modify the commands.
+		AfterBuild func(ctx context.Context, cmds Commands) (Commands, error)
+		// BeforeExec is called for each command
+		// executed by the engine. It may modify
+		// the command or skip it with ErrSkip.
+		BeforeExec func(ctx context.Context, cmd Command) (Command, error)
+		// AfterExec receives the error of the
+		// command, which it may replace.
+		AfterExec func(ctx context.Context, cmd Command, err error) error
+	}
+
+	hooks []Hook
+
+	hooksKey struct{}
+)
+
+// Hooks option adds hooks around parse,
+// build and exec, called in order. Exec
+// hooks require the engine to execute its
+// commands through RunCommand, as the
+// engines of this module do.
+func Hooks(h ...Hook) Option {
+	return func(p *Polluter) {
+		p.hooks = append(p.hooks, h...)
+	}
+}
+
+// RunCommand executes cmd with exec, calling the
+// exec hooks, the logger and the tracer of the
+// polluter or engine which passed ctx. Engines call it for
+// each command they execute.
+func RunCommand(ctx context.Context, cmd Command, exec func(Command) error) error {
+	cmd, err := PrepareCommand(ctx, cmd)
+	if errors.Is(err, ErrSkip) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return RunPrepared(ctx, cmd, exec)
+}
+
+// PrepareCommand calls the BeforeExec hooks
+// of ctx on cmd, and returns ErrSkip when one
+// skips it. Engines which queue commands and
+// run them later, like batches, call it when
+// they queue cmd and RunPrepared when they
+// read its result.
+func PrepareCommand(ctx context.Context, cmd Command) (Command, error) {
+	hs, _ := ctx.Value(hooksKey{}).(hooks)
+
+	for _, h := range hs {
+		if h.BeforeExec == nil {
+			continue
+		}
+		var err error
+		if cmd, err = h.BeforeExec(ctx, cmd); err != nil {
+			return cmd, err
+		}
+	}
+
+	return cmd, nil
+}
+
+// RunPrepared executes a command returned by
+// PrepareCommand with exec, calling the logger,
+// the tracer and the AfterExec hooks of ctx.
+func RunPrepared(ctx context.Context, cmd Command, exec func(Command) error) error {
+	var err error
+	if l, ok := ctx.Value(loggerKey{}).(*CommandLogger); ok {
+		err = l.run(cmd, func(cmd Command) error {
+			return runTraced(ctx, cmd, exec)
+		})
+	} else {
+		err = runTraced(ctx, cmd, exec)
+	}
+
+	hs, _ := ctx.Value(hooksKey{}).(hooks)
+	for _, h := range hs {
+		if h.AfterExec != nil {
+			err = h.AfterExec(ctx, cmd, err)
+		}
+	}
+
+	return err
+}
+
+func (hs hooks) context(ctx context.Context) context.Context {
+	if len(hs) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, hooksKey{}, hs)
+}
+
+func (hs hooks) beforeParse(ctx context.Context, r io.Reader) (io.Reader, error) {
+	for _, h := range hs {
+		if h.BeforeParse == nil {
+			continue
+		}
+		var err error
+		if r, err = h.BeforeParse(ctx, r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (hs hooks) afterParse(ctx context.Context, obj jwalk.ObjectWalker) (jwalk.ObjectWalker, error) {
+	if !hs.has(func(h Hook) bool { return h.AfterParse != nil }) {
+		return obj, nil
+	}
+
+	doc, err := RecordOf(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "read records")
+	}
+	for _, h := range hs {
+		if h.AfterParse == nil {
+			continue
+		}
+		if doc, err = h.AfterParse(ctx, doc); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc.Walker()
+}
+
+func (hs hooks) beforeBuild(ctx context.Context, doc Record) (Record, error) {
+	if !hs.has(func(h Hook) bool { return h.BeforeBuild != nil }) {
+		return doc, nil
+	}
+
+	res := make(Record, 0, len(doc))
+	for _, f := range doc {
+		switch v := f.Value.(type) {
+		case []Record:
+			rows := make([]Record, 0, len(v))
+			for i, row := range v {
+				r, err := hs.buildRow(ctx, f.Name, row)
+				if errors.Is(err, ErrSkip) {
+					continue
+				}
+				if err != nil {
+					return nil, errors.Wrapf(err, "%s[%d]", f.Name, i)
+				}
+				rows = append(rows, r)
+			}
+			res = append(res, Field{Name: f.Name, Value: rows})
+		case Record:
+			r, err := hs.buildRow(ctx, f.Name, v)
+			if errors.Is(err, ErrSkip) {
+				continue
+			}
+			if err != nil {
+				return nil, errors.Wrap(err, f.Name)
+			}
+			res = append(res, Field{Name: f.Name, Value: r})
+		default:
+			res = append(res, f)
+		}
+	}
+
+	return res, nil
+}
+
+func (hs hooks) buildRow(ctx context.Context, table string, row Record) (Record, error) {
+	for _, h := range hs {
+		if h.BeforeBuild == nil {
+			continue
+		}
+		var err error
+		if row, err = h.BeforeBuild(ctx, table, row); err != nil {
+			return nil, err
+		}
+	}
+	return row, nil
+}
+
+func (hs hooks) afterBuild(ctx context.Context, cmds Commands) (Commands, error) {
+	for _, h := range hs {
+		if h.AfterBuild == nil {
+			continue
+		}
+		var err error
+		if cmds, err = h.AfterBuild(ctx, cmds); err != nil {
+			return nil, err
+		}
+	}
+	return cmds, nil
+}
+
+func (hs hooks) has(fn func(Hook) bool) bool {
+	for _, h := range hs {
+		if fn(h) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,222 @@
+package polluter_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/romanyx/jwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+// hookedEngine builds a command per row
+// and runs them through RunCommand.
+type hookedEngine struct {
+	executed *[]polluter.Command
+}
+
+func (e hookedEngine) Build(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+	doc, err := polluter.RecordOf(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make(polluter.Commands, 0)
+	for _, f := range doc {
+		rows, _ := f.Value.([]polluter.Record)
+		for _, r := range rows {
+			data, err := json.Marshal(r)
+			if err != nil {
+				return nil, err
+			}
+			cmds = append(cmds, polluter.Command{Q: f.Name, Args: []interface{}{string(data)}, Table: f.Name, Rows: 1})
+		}
+	}
+	return cmds, nil
+}
+
+func (e hookedEngine) Exec(cmds polluter.Commands) error {
+	return e.ExecContext(context.Background(), cmds)
+}
+
+func (e hookedEngine) ExecContext(ctx context.Context, cmds polluter.Commands) error {
+	for _, c := range cmds {
+		if err := polluter.RunCommand(ctx, c, func(c polluter.Command) error {
+			if c.Q == "broken" {
+				return errors.New("broken")
+			}
+			*e.executed = append(*e.executed, c)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestHooks(t *testing.T) {
+	input := "users:\n- name: Roman\n- name: Dmitry\n"
+	errAbort := errors.New("abort")
+
+	tests := []struct {
+		name    string
+		hooks   []polluter.Hook
+		expect  polluter.Commands
+		wantErr error
+	}{
+		{
+			name:   "no hooks",
+			expect: polluter.Commands{{Q: "users", Args: []interface{}{`{"name":"Roman"}`}, Table: "users", Rows: 1}, {Q: "users", Args: []interface{}{`{"name":"Dmitry"}`}, Table: "users", Rows: 1}},
+		},
+		{
+			name: "parse hooks",
+			hooks: []polluter.Hook{{
+				BeforeParse: func(_ context.Context, r io.Reader) (io.Reader, error) {
+					return io.MultiReader(r, strings.NewReader("roles:\n- name: User\n")), nil
+				},
+				AfterParse: func(_ context.Context, doc polluter.Record) (polluter.Record, error) {
+					return doc.Delete("users"), nil
+				},
+			}},
+			expect: polluter.Commands{{Q: "roles", Args: []interface{}{`{"name":"User"}`}, Table: "roles", Rows: 1}},
+		},
+		{
+			name: "build hooks modify and skip rows",
+			hooks: []polluter.Hook{{
+				BeforeBuild: func(_ context.Context, table string, row polluter.Record) (polluter.Record, error) {
+					if name, _ := row.Get("name"); name == "Dmitry" {
+						return nil, fmt.Errorf("%s: %w", name, polluter.ErrSkip)
+					}
+					return row.Set("tenant_id", 42), nil
+				},
+				AfterBuild: func(_ context.Context, cmds polluter.Commands) (polluter.Commands, error) {
+					return append(cmds, polluter.Command{Q: "audit"}), nil
+				},
+			}},
+			expect: polluter.Commands{{Q: "users", Args: []interface{}{`{"name":"Roman","tenant_id":42}`}, Table: "users", Rows: 1}, {Q: "audit"}},
+		},
+		{
+			name: "exec hooks modify and skip commands",
+			hooks: []polluter.Hook{{
+				BeforeExec: func(_ context.Context, cmd polluter.Command) (polluter.Command, error) {
+					if strings.Contains(cmd.Args[0].(string), "Dmitry") {
+						return cmd, polluter.ErrSkip
+					}
+					cmd.Q = "people"
+					return cmd, nil
+				},
+			}},
+			expect: polluter.Commands{{Q: "people", Args: []interface{}{`{"name":"Roman"}`}, Table: "users", Rows: 1}},
+		},
+		{
+			name: "after exec replaces the error",
+			hooks: []polluter.Hook{{
+				BeforeExec: func(_ context.Context, cmd polluter.Command) (polluter.Command, error) {
+					cmd.Q = "broken"
+					return cmd, nil
+				},
+				AfterExec: func(_ context.Context, cmd polluter.Command, err error) error {
+					if err != nil {
+						return nil
+					}
+					return err
+				},
+			}},
+		},
+		{
+			name: "abort",
+			hooks: []polluter.Hook{{
+				BeforeBuild: func(context.Context, string, polluter.Record) (polluter.Record, error) {
+					return nil, errAbort
+				},
+			}},
+			wantErr: errAbort,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var executed []polluter.Command
+			p := polluter.New(hookedEngine{executed: &executed}, yaml.YAMLParser(), polluter.Hooks(tt.hooks...))
+			err := p.Pollute(strings.NewReader(input))
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "%v", err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, []polluter.Command(tt.expect), executed)
+		})
+	}
+}
+
+// batchEngine queues the commands built by
+// hookedEngine and runs them at the end.
+type batchEngine struct {
+	hookedEngine
+}
+
+func (e batchEngine) Exec(cmds polluter.Commands) error {
+	return e.ExecContext(context.Background(), cmds)
+}
+
+func (e batchEngine) ExecContext(ctx context.Context, cmds polluter.Commands) error {
+	queued := make(polluter.Commands, 0, len(cmds))
+	for _, c := range cmds {
+		c, err := polluter.PrepareCommand(ctx, c)
+		if errors.Is(err, polluter.ErrSkip) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		queued = append(queued, c)
+	}
+
+	for _, c := range queued {
+		if err := polluter.RunPrepared(ctx, c, func(c polluter.Command) error {
+			*e.executed = append(*e.executed, c)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestPrepareCommand(t *testing.T) {
+	var (
+		events   []string
+		executed []polluter.Command
+	)
+	p := polluter.New(batchEngine{hookedEngine{executed: &executed}}, yaml.YAMLParser(), polluter.Hooks(polluter.Hook{
+		BeforeExec: func(_ context.Context, cmd polluter.Command) (polluter.Command, error) {
+			if strings.Contains(cmd.Args[0].(string), "Dmitry") {
+				return cmd, polluter.ErrSkip
+			}
+			events = append(events, "before "+cmd.Args[0].(string))
+			return cmd, nil
+		},
+		AfterExec: func(_ context.Context, cmd polluter.Command, err error) error {
+			events = append(events, "after "+cmd.Args[0].(string))
+			return err
+		},
+	}))
+
+	err := p.Pollute(strings.NewReader("users:\n- name: Roman\n- name: Dmitry\n- name: Anna\n"))
+	assert.Nil(t, err)
+	assert.Len(t, executed, 2)
+	assert.Equal(t, []string{
+		`before {"name":"Roman"}`, `before {"name":"Anna"}`,
+		`after {"name":"Roman"}`, `after {"name":"Anna"}`,
+	}, events)
+}
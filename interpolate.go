@@ -0,0 +1,122 @@
+package polluter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Interpolate option enables ${VAR} expansion
+// in table names and values. Variables are
+// looked up in vars first, then in the
+// environment. An undefined variable is an
+// error unless a default is given, as in
+// ${VAR:-default}. Use $$ for a literal $.
+// With Generators, $$ before a generator
+// expression is left for it to unescape.
+//
+// A value made of a single variable takes
+// the type of the variable, so ${ID} with
+// vars{"ID": 1} inserts a number.
+func Interpolate(vars map[string]interface{}) Option {
+	return func(p *Polluter) {
+		p.interpolate = true
+		p.vars = vars
+	}
+}
+
+func (p *Polluter) lookup(name string) (interface{}, bool) {
+	if v, ok := p.vars[name]; ok {
+		return v, true
+	}
+	if v, ok := os.LookupEnv(name); ok {
+		return v, true
+	}
+	return nil, false
+}
+
+func interpolateRecord(r Record, lookup func(string) (interface{}, bool), generators bool) (Record, error) {
+	res := make(Record, 0, len(r))
+	for _, f := range r {
+		name, err := expand(f.Name, lookup, generators)
+		if err != nil {
+			return nil, errors.Wrap(err, f.Name)
+		}
+
+		value, err := mapStrings(f.Value, func(s string) (interface{}, error) {
+			return expand(s, lookup, generators)
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, f.Name)
+		}
+
+		res = append(res, Field{Name: fmt.Sprint(name), Value: value})
+	}
+	return res, nil
+}
+
+// expand replaces variables of s. With
+// generators, an escaped generator expression
+// keeps its $$ for generate.
+func expand(s string, lookup func(string) (interface{}, bool), generators bool) (interface{}, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	if generators && strings.HasPrefix(s, "$$") && generatorExpr.MatchString(s) {
+		v, err := expand(s[1:], lookup, generators)
+		if err != nil {
+			return nil, err
+		}
+		return "$" + fmt.Sprint(v), nil
+	}
+	if strings.HasPrefix(s, "${") && strings.IndexByte(s, '}') == len(s)-1 {
+		return resolve(s[2:len(s)-1], lookup)
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return nil, errors.Errorf("unterminated variable in %q", s)
+			}
+
+			v, err := resolve(s[i+2:i+end], lookup)
+			if err != nil {
+				return nil, err
+			}
+			b.WriteString(fmt.Sprint(v))
+			i += end
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), nil
+}
+
+func resolve(ref string, lookup func(string) (interface{}, bool)) (interface{}, error) {
+	name, def, hasDef := ref, "", false
+	if i := strings.Index(ref, ":-"); i >= 0 {
+		name, def, hasDef = ref[:i], ref[i+2:], true
+	}
+
+	if v, ok := lookup(name); ok {
+		return v, nil
+	}
+	if hasDef {
+		return def, nil
+	}
+	return nil, errors.Errorf("undefined variable %s", name)
+}
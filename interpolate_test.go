@@ -0,0 +1,91 @@
+package polluter_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser"
+	"github.com/quen2404/polluter/parser/json"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/romanyx/jwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolate(t *testing.T) {
+	os.Setenv("POLLUTER_TEST_TENANT", "acme")
+	t.Cleanup(func() {
+		os.Unsetenv("POLLUTER_TEST_TENANT")
+	})
+
+	vars := map[string]interface{}{
+		"ID":   7,
+		"DATE": "2020-01-02",
+	}
+
+	tests := []struct {
+		name    string
+		parser  parser.Parser
+		input   string
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:   "yaml",
+			parser: yaml.YAMLParser(),
+			input: `${POLLUTER_TEST_TENANT}_users:
+- id: ${ID}
+  name: ${NAME:-Roman}
+  created_at: "${DATE} 10:00:00"
+  price: $$5
+`,
+			expect: `{"acme_users":[{"id":7,"name":"Roman","created_at":"2020-01-02 10:00:00","price":"$5"}]}`,
+		},
+		{
+			name:   "json",
+			parser: json.JSONParser(),
+			input:  `{"users":[{"id":"${ID}","tenant":"${POLLUTER_TEST_TENANT}","tags":["${DATE}"]}]}`,
+			expect: `{"users":[{"id":7,"tenant":"acme","tags":["2020-01-02"]}]}`,
+		},
+		{
+			name:    "undefined variable",
+			parser:  yaml.YAMLParser(),
+			input:   "users:\n- name: ${POLLUTER_TEST_UNDEFINED}\n",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated variable",
+			parser:  json.JSONParser(),
+			input:   `{"users":[{"name":"a ${ID"}]}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []byte
+			engine := buildFunc(func(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+				var err error
+				got, err = obj.MarshalJSON()
+				return nil, err
+			})
+
+			p := polluter.New(engine, tt.parser, polluter.Interpolate(vars))
+			err := p.Pollute(strings.NewReader(tt.input))
+
+			if tt.wantErr && err == nil {
+				assert.NotNil(t, err)
+				return
+			}
+
+			if !tt.wantErr {
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expect, string(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package polluter
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+type (
+	// Logger receives the messages of a
+	// CommandLogger. *log.Logger is one.
+	Logger interface {
+		Printf(format string, v ...interface{})
+	}
+
+	// LogOption configures a CommandLogger.
+	LogOption func(*CommandLogger)
+
+	// CommandLogger logs the commands run
+	// by RunCommand with their duration.
+	CommandLogger struct {
+		logger Logger
+		slow   time.Duration
+		redact func(Command) Command
+	}
+
+	loggerKey struct{}
+)
+
+// NewCommandLogger returns a CommandLogger
+// writing to l.
+func NewCommandLogger(l Logger, opts ...LogOption) *CommandLogger {
+	c := &CommandLogger{logger: l}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// SlowThreshold option logs a warning for
+// commands which take longer than d.
+func SlowThreshold(d time.Duration) LogOption {
+	return func(c *CommandLogger) {
+		c.slow = d
+	}
+}
+
+// Redact option rewrites commands before they
+// are logged, e.g. to hide passwords. The
+// executed command is left untouched.
+func Redact(fn func(Command) Command) LogOption {
+	return func(c *CommandLogger) {
+		c.redact = fn
+	}
+}
+
+// Log option logs the commands executed by
+// the engine, their duration and slow ones.
+// Engines must run their commands through
+// RunCommand, as those of this module do.
+func Log(l Logger, opts ...LogOption) Option {
+	return func(p *Polluter) {
+		p.logger = NewCommandLogger(l, opts...)
+	}
+}
+
+// Context returns a copy of ctx in which
+// RunCommand logs to c. Engines with their
+// own logger call it before executing.
+// It returns ctx when c is nil.
+func (c *CommandLogger) Context(ctx context.Context) context.Context {
+	if c == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, loggerKey{}, c)
+}
+
+func (c *CommandLogger) run(cmd Command, exec func(Command) error) error {
+	start := time.Now()
+	err := exec(cmd)
+	d := time.Since(start)
+
+	if c.redact != nil {
+		cmd = c.redact(cmd)
+	}
+
+	args := make([]string, 0, len(cmd.Args))
+	for _, a := range cmd.Args {
+		args = append(args, format(a))
+	}
+	line := cmd.Q
+	if len(args) > 0 {
+		line = line + " [" + strings.Join(args, ", ") + "]"
+	}
+
+	if err != nil {
+		c.logger.Printf("polluter: %s failed after %s: %s", line, d, err)
+		return err
+	}
+	if c.slow > 0 && d > c.slow {
+		c.logger.Printf("polluter: slow command took %s, over %s: %s", d, c.slow, line)
+		return nil
+	}
+	c.logger.Printf("polluter: %s (%s)", line, d)
+	return nil
+}
@@ -0,0 +1,94 @@
+package polluter_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+type linesLogger struct {
+	lines *[]string
+}
+
+func (l linesLogger) Printf(format string, v ...interface{}) {
+	*l.lines = append(*l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestLog(t *testing.T) {
+	input := "users:\n- name: Roman\nbroken:\n- name: Dmitry\n"
+
+	tests := []struct {
+		name   string
+		input  string
+		opts   []polluter.LogOption
+		expect []string
+	}{
+		{
+			name:  "commands and total",
+			input: "users:\n- name: Roman\n",
+			expect: []string{
+				`polluter: users ["{\"name\":\"Roman\"}"] (`,
+				"polluter: executed 1 command(s) in ",
+			},
+		},
+		{
+			name:  "redacted failure",
+			input: input,
+			opts: []polluter.LogOption{polluter.Redact(func(c polluter.Command) polluter.Command {
+				c.Args = nil
+				return c
+			})},
+			expect: []string{
+				"polluter: users (",
+				"polluter: broken failed after ",
+			},
+		},
+		{
+			name:  "slow commands",
+			input: "users:\n- name: Roman\n",
+			opts:  []polluter.LogOption{polluter.SlowThreshold(time.Nanosecond)},
+			expect: []string{
+				"polluter: slow command took ",
+				"polluter: executed 1 command(s) in ",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var lines []string
+			var executed []polluter.Command
+			p := polluter.New(hookedEngine{executed: &executed}, yaml.YAMLParser(), polluter.Log(linesLogger{&lines}, tt.opts...))
+			_ = p.Pollute(strings.NewReader(tt.input))
+
+			if assert.Len(t, lines, len(tt.expect), "%q", lines) {
+				for i, prefix := range tt.expect {
+					assert.True(t, strings.HasPrefix(lines[i], prefix), "%q", lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCommandLogger_Context(t *testing.T) {
+	var lines []string
+	ctx := polluter.NewCommandLogger(linesLogger{&lines}).Context(context.Background())
+
+	err := polluter.RunCommand(ctx, polluter.Command{Q: "SELECT 1;"}, func(polluter.Command) error { return nil })
+	assert.Nil(t, err)
+	if assert.Len(t, lines, 1) {
+		assert.True(t, strings.HasPrefix(lines[0], "polluter: SELECT 1; ("), lines[0])
+	}
+
+	var nilLogger *polluter.CommandLogger
+	assert.Equal(t, context.Background(), nilLogger.Context(context.Background()))
+}
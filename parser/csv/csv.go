@@ -0,0 +1,290 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/quen2404/polluter/parser"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/romanyx/jwalk"
+)
+
+// Kind tells how the values of a column
+// are converted. Columns are strings
+// unless told otherwise.
+type Kind int
+
+const (
+	String Kind = iota
+	Int
+	Float
+	Bool
+	// JSON columns hold raw JSON documents,
+	// useful for arrays and nested objects.
+	JSON
+)
+
+type config struct {
+	delimiter rune
+	null      string
+	hasNull   bool
+	types     map[string]Kind
+	order     []string
+}
+
+// Option configures CSV parsing.
+type Option func(*config)
+
+// Delimiter option sets the field
+// delimiter, comma by default.
+func Delimiter(r rune) Option {
+	return func(c *config) {
+		c.delimiter = r
+	}
+}
+
+// Null option sets the marker which
+// is read as NULL, e.g. `\N` or `NULL`.
+func Null(marker string) Option {
+	return func(c *config) {
+		c.null = marker
+		c.hasNull = true
+	}
+}
+
+// Type option sets the kind of a column.
+// The column is either a bare name, applied
+// to every table, or qualified with the
+// table as in "users.id".
+func Type(column string, kind Kind) Option {
+	return func(c *config) {
+		c.types[column] = kind
+	}
+}
+
+// Order option sets the order in which
+// tables of a directory are seeded. Tables
+// not listed follow in alphabetical order.
+func Order(tables ...string) Option {
+	return func(c *config) {
+		c.order = append(c.order, tables...)
+	}
+}
+
+func newConfig(opts []Option) config {
+	c := config{
+		delimiter: ',',
+		types:     make(map[string]Kind),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+func (c config) kind(table, column string) Kind {
+	if k, ok := c.types[table+"."+column]; ok {
+		return k
+	}
+	return c.types[column]
+}
+
+type csvParser struct {
+	table string
+	cfg   config
+}
+
+func (p csvParser) Parse(r io.Reader) (jwalk.ObjectWalker, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString("{")
+	if err := handleTable(p.table, r, p.cfg, buf); err != nil {
+		return nil, err
+	}
+	buf.WriteString("}")
+
+	return walker(buf.Bytes())
+}
+
+func handleTable(table string, r io.Reader, cfg config, buf *bytes.Buffer) error {
+	cr := csv.NewReader(r)
+	cr.Comma = cfg.delimiter
+
+	header, err := cr.Read()
+	if err != nil {
+		return errors.Wrapf(err, "read header of %s", table)
+	}
+
+	writeString(table, buf)
+	buf.WriteString(":[")
+	for i := 0; ; i++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrapf(err, "read %s", table)
+		}
+
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("{")
+		for j, column := range header {
+			if j > 0 {
+				buf.WriteString(",")
+			}
+			writeString(column, buf)
+			buf.WriteString(":")
+			if err := formatValue(record[j], cfg.kind(table, column), cfg, buf); err != nil {
+				return errors.Wrapf(err, "%s record %d column %s", table, i, column)
+			}
+		}
+		buf.WriteString("}")
+	}
+	buf.WriteString("]")
+
+	return nil
+}
+
+func formatValue(v string, kind Kind, cfg config, buf *bytes.Buffer) error {
+	if cfg.hasNull && v == cfg.null {
+		buf.WriteString("null")
+		return nil
+	}
+
+	switch kind {
+	case Int:
+		n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid int")
+		}
+		buf.WriteString(strconv.FormatInt(n, 10))
+	case Float:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid float")
+		}
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return errors.Errorf("invalid float %s", v)
+		}
+		buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	case Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return errors.Wrap(err, "invalid bool")
+		}
+		buf.WriteString(strconv.FormatBool(b))
+	case JSON:
+		if !json.Valid([]byte(v)) {
+			return errors.New("invalid json")
+		}
+		buf.WriteString(v)
+	default:
+		writeString(v, buf)
+	}
+
+	return nil
+}
+
+func writeString(s string, buf *bytes.Buffer) {
+	data, _ := json.Marshal(s)
+	buf.Write(data)
+}
+
+func walker(data []byte) (jwalk.ObjectWalker, error) {
+	i, err := jwalk.Parse(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse")
+	}
+
+	obj, ok := i.(jwalk.ObjectWalker)
+	if !ok {
+		return nil, errors.New("unexpected format")
+	}
+
+	return obj, nil
+}
+
+// ParseDir reads every .csv file of the directory
+// as a table named after the file, so users.csv
+// seeds the users table.
+func ParseDir(dir string, opts ...Option) (jwalk.ObjectWalker, error) {
+	cfg := newConfig(opts)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "read dir")
+	}
+
+	tables := make([]string, 0)
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".csv" {
+			continue
+		}
+		tables = append(tables, strings.TrimSuffix(f.Name(), ".csv"))
+	}
+	sortTables(tables, cfg.order)
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("{")
+	for i, table := range tables {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		if err := handleFile(table, filepath.Join(dir, table+".csv"), cfg, buf); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteString("}")
+
+	return walker(buf.Bytes())
+}
+
+func handleFile(table, path string, cfg config, buf *bytes.Buffer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "open file")
+	}
+	defer f.Close()
+
+	return handleTable(table, f, cfg, buf)
+}
+
+func sortTables(tables []string, order []string) {
+	rank := func(table string) int {
+		for i, t := range order {
+			if t == table {
+				return i
+			}
+		}
+		return len(order)
+	}
+
+	sort.SliceStable(tables, func(i, j int) bool {
+		ri, rj := rank(tables[i]), rank(tables[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return tables[i] < tables[j]
+	})
+}
+
+// CSVParser option enables CSV parsing
+// engine for seeding. The header row
+// holds the columns and every other row
+// becomes a record of the given table.
+func CSVParser(table string, opts ...Option) parser.Parser {
+	return csvParser{
+		table: table,
+		cfg:   newConfig(opts),
+	}
+}
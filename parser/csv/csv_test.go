@@ -0,0 +1,120 @@
+package csv
+
+import (
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_csvParser_parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     io.Reader
+		opts    []Option
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:   "strings by default",
+			arg:    strings.NewReader("id,name\n1,Roman\n2,Dmitry\n"),
+			expect: `{"users":[{"id":"1","name":"Roman"},{"id":"2","name":"Dmitry"}]}`,
+		},
+		{
+			name: "type hints, null and delimiter",
+			arg:  strings.NewReader("id;name;admin;score;tags\n1;Roman;true;1.5;[1,2]\n2;\\N;false;\\N;{}\n"),
+			opts: []Option{
+				Delimiter(';'),
+				Null(`\N`),
+				Type("users.id", Int),
+				Type("admin", Bool),
+				Type("score", Float),
+				Type("tags", JSON),
+			},
+			expect: `{"users":[{"id":1,"name":"Roman","admin":true,"score":1.5,"tags":[1,2]},{"id":2,"name":null,"admin":false,"score":null,"tags":{}}]}`,
+		},
+		{
+			name:   "canonical numbers",
+			arg:    strings.NewReader("id,score\n+5,1.50\n007,-0\n"),
+			opts:   []Option{Type("id", Int), Type("score", Float)},
+			expect: `{"users":[{"id":5,"score":1.5},{"id":7,"score":-0}]}`,
+		},
+		{
+			name:    "NaN float",
+			arg:     strings.NewReader("id,score\n1,NaN\n"),
+			opts:    []Option{Type("score", Float)},
+			wantErr: true,
+		},
+		{
+			name:    "infinite float",
+			arg:     strings.NewReader("id,score\n1,-Inf\n"),
+			opts:    []Option{Type("score", Float)},
+			wantErr: true,
+		},
+		{
+			name:    "invalid int",
+			arg:     strings.NewReader("id,name\nx,Roman\n"),
+			opts:    []Option{Type("id", Int)},
+			wantErr: true,
+		},
+		{
+			name:    "wrong number of fields",
+			arg:     strings.NewReader("id,name\n1\n"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := CSVParser("users", tt.opts...)
+			w, err := p.Parse(tt.arg)
+
+			if tt.wantErr && err == nil {
+				assert.NotNil(t, err)
+				return
+			}
+
+			if !tt.wantErr {
+				if err != nil {
+					assert.Nil(t, err)
+					return
+				}
+
+				data, err := w.MarshalJSON()
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expect, string(data))
+			}
+		})
+	}
+}
+
+func TestParseDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"users.csv":  "id,name,role_id\n1,Roman,1\n",
+		"roles.csv":  "id,name\n1,User\n",
+		"groups.csv": "id\n1\n",
+		"notes.txt":  "ignored",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w, err := ParseDir(dir, Order("roles"), Type("id", Int), Type("role_id", Int))
+	if err != nil {
+		assert.Nil(t, err)
+		return
+	}
+
+	data, err := w.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"roles":[{"id":1,"name":"User"}],"groups":[{"id":1}],"users":[{"id":1,"name":"Roman","role_id":1}]}`, string(data))
+}
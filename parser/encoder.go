@@ -0,0 +1,13 @@
+package parser
+
+import (
+	"github.com/romanyx/jwalk"
+	"io"
+)
+
+// Encoder is implemented by parsers which
+// can also write fixtures, e.g. to dump
+// the contents of a database.
+type Encoder interface {
+	Encode(io.Writer, jwalk.ObjectWalker) error
+}
@@ -0,0 +1,307 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/romanyx/jwalk"
+)
+
+// IncludeKey is the top level key of a
+// fixture listing the fixtures it extends.
+const IncludeKey = "include"
+
+type (
+	// Options configure parsers which
+	// resolve includes.
+	Options struct {
+		// FS holds included fixtures.
+		// The OS file system is used
+		// when nil.
+		FS fs.FS
+		// Dir is the directory the paths
+		// included by the parsed input are
+		// relative to. When the input is a
+		// file, its directory is used.
+		Dir string
+	}
+
+	// Option configures a parser.
+	Option func(*Options)
+
+	// Decoder decodes a single fixture,
+	// includes left unresolved.
+	Decoder func([]byte) (jwalk.ObjectWalker, error)
+)
+
+// FS option reads included fixtures from fsys.
+func FS(fsys fs.FS) Option {
+	return func(o *Options) {
+		o.FS = fsys
+	}
+}
+
+// Dir option sets the directory the
+// includes of the input are relative to.
+func Dir(dir string) Option {
+	return func(o *Options) {
+		o.Dir = dir
+	}
+}
+
+// NewOptions applies opts.
+func NewOptions(opts ...Option) Options {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Include decodes data read from r and
+// resolves its includes:
+//
+//	include:
+//	- base.yml
+//	- roles.yml
+//	users:
+//	- name: Roman
+//
+// Paths are relative to the including
+// fixture. Included fixtures come first, in
+// the listed order, then the including one.
+// Rows of a table found in several fixtures
+// are concatenated, other values are replaced
+// by the last one. A fixture included twice
+// is only merged once and cycles are errors.
+func Include(r io.Reader, data []byte, decode Decoder, o Options) (jwalk.ObjectWalker, error) {
+	name := ""
+	if f, ok := r.(interface{ Name() string }); ok && o.FS == nil && o.Dir == "" {
+		name = f.Name()
+	}
+
+	i := includer{
+		opts:   o,
+		decode: decode,
+		seen:   make(map[string]bool),
+	}
+	return i.load(name, data)
+}
+
+type includer struct {
+	opts   Options
+	decode Decoder
+	stack  []string
+	seen   map[string]bool
+}
+
+func (i *includer) load(name string, data []byte) (jwalk.ObjectWalker, error) {
+	for _, n := range i.stack {
+		if n == name {
+			return nil, errors.Errorf("include cycle: %s", strings.Join(append(i.stack, name), " -> "))
+		}
+	}
+	i.stack = append(i.stack, name)
+	defer func() {
+		i.stack = i.stack[:len(i.stack)-1]
+	}()
+
+	obj, err := i.decode(data)
+	if err != nil {
+		if name != "" {
+			return nil, errors.Wrap(err, name)
+		}
+		return nil, err
+	}
+
+	var includes []string
+	if err := obj.Walk(func(key string, value interface{}) error {
+		if key != IncludeKey {
+			return nil
+		}
+		includes, err = includePaths(value)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	if includes == nil {
+		return obj, nil
+	}
+
+	parts := make([]jwalk.ObjectWalker, 0, len(includes)+1)
+	for _, inc := range includes {
+		p := i.resolve(name, inc)
+		if i.seen[p] {
+			continue
+		}
+
+		data, err := i.read(p)
+		if err != nil {
+			return nil, errors.Wrapf(err, "include %s", inc)
+		}
+
+		part, err := i.load(p, data)
+		if err != nil {
+			return nil, err
+		}
+		i.seen[p] = true
+		parts = append(parts, part)
+	}
+	parts = append(parts, obj)
+
+	return Merge(parts...)
+}
+
+func includePaths(value interface{}) ([]string, error) {
+	v, ok := value.(jwalk.Value)
+	if !ok {
+		return nil, errors.New("include must be a path or a list of paths")
+	}
+
+	switch i := v.Interface().(type) {
+	case string:
+		return []string{i}, nil
+	case []interface{}:
+		paths := make([]string, 0, len(i))
+		for _, p := range i {
+			s, ok := p.(string)
+			if !ok {
+				return nil, errors.New("include must be a path or a list of paths")
+			}
+			paths = append(paths, s)
+		}
+		return paths, nil
+	default:
+		return nil, errors.New("include must be a path or a list of paths")
+	}
+}
+
+func (i *includer) resolve(name, inc string) string {
+	if i.opts.FS != nil {
+		dir := i.opts.Dir
+		if name != "" {
+			dir = path.Dir(name)
+		}
+		return path.Join(dir, inc)
+	}
+
+	if filepath.IsAbs(inc) {
+		return filepath.Clean(inc)
+	}
+	dir := i.opts.Dir
+	if name != "" {
+		dir = filepath.Dir(name)
+	}
+	return filepath.Join(dir, inc)
+}
+
+func (i *includer) read(name string) ([]byte, error) {
+	if i.opts.FS != nil {
+		return fs.ReadFile(i.opts.FS, name)
+	}
+	return ioutil.ReadFile(name)
+}
+
+// Merge merges fixtures in order: rows of
+// a table are concatenated, other values
+// are replaced. The include key is dropped.
+func Merge(objs ...jwalk.ObjectWalker) (jwalk.ObjectWalker, error) {
+	var (
+		keys   []string
+		values = make(map[string][]interface{})
+	)
+	for _, obj := range objs {
+		if err := obj.Walk(func(key string, value interface{}) error {
+			if key == IncludeKey {
+				return nil
+			}
+			if _, ok := values[key]; !ok {
+				keys = append(keys, key)
+			}
+
+			if _, ok := value.(jwalk.ObjectsWalker); !ok {
+				values[key] = nil
+			} else if len(values[key]) > 0 {
+				if _, ok := values[key][0].(jwalk.ObjectsWalker); !ok {
+					values[key] = nil
+				}
+			}
+			values[key] = append(values[key], value)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	buf.WriteString("{")
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteString(":")
+
+		if err := writeValues(values[key], buf); err != nil {
+			return nil, errors.Wrap(err, key)
+		}
+	}
+	buf.WriteString("}")
+
+	v, err := jwalk.Parse(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse")
+	}
+
+	obj, ok := v.(jwalk.ObjectWalker)
+	if !ok {
+		return nil, errors.New("unexpected format")
+	}
+
+	return obj, nil
+}
+
+func writeValues(values []interface{}, buf *bytes.Buffer) error {
+	if _, ok := values[0].(jwalk.ObjectsWalker); !ok {
+		data, err := values[0].(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		return nil
+	}
+
+	buf.WriteString("[")
+	first := true
+	for _, v := range values {
+		if err := v.(jwalk.ObjectsWalker).Walk(func(obj jwalk.ObjectWalker) error {
+			if !first {
+				buf.WriteString(",")
+			}
+			first = false
+
+			data, err := obj.MarshalJSON()
+			if err != nil {
+				return err
+			}
+			buf.Write(data)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("]")
+
+	return nil
+}
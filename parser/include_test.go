@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/pkg/errors"
+	"github.com/romanyx/jwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeJSON(data []byte) (jwalk.ObjectWalker, error) {
+	i, err := jwalk.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := i.(jwalk.ObjectWalker)
+	if !ok {
+		return nil, errors.New("unexpected format")
+	}
+	return obj, nil
+}
+
+func TestInclude(t *testing.T) {
+	fsys := fstest.MapFS{
+		"base.json":        {Data: []byte(`{"roles":[{"id":1}],"count":1}`)},
+		"users/users.json": {Data: []byte(`{"include":"../base.json","users":[{"id":1}]}`)},
+		"users/more.json":  {Data: []byte(`{"include":["../base.json","users.json"],"users":[{"id":2}]}`)},
+		"cycle/a.json":     {Data: []byte(`{"include":"b.json"}`)},
+		"cycle/b.json":     {Data: []byte(`{"include":["a.json"]}`)},
+		"invalid.json":     {Data: []byte(`{"include":1}`)},
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		opts    []Option
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:   "no includes",
+			input:  `{"users":[{"id":1}]}`,
+			opts:   []Option{FS(fsys)},
+			expect: `{"users":[{"id":1}]}`,
+		},
+		{
+			name:   "nested includes merged once in order",
+			input:  `{"include":["users/more.json"],"roles":[{"id":2}],"count":2}`,
+			opts:   []Option{FS(fsys)},
+			expect: `{"roles":[{"id":1},{"id":2}],"count":2,"users":[{"id":1},{"id":2}]}`,
+		},
+		{
+			name:   "relative to dir",
+			input:  `{"include":"users.json"}`,
+			opts:   []Option{FS(fsys), Dir("users")},
+			expect: `{"roles":[{"id":1}],"count":1,"users":[{"id":1}]}`,
+		},
+		{
+			name:    "cycle",
+			input:   `{"include":"cycle/a.json"}`,
+			opts:    []Option{FS(fsys)},
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			input:   `{"include":"missing.json"}`,
+			opts:    []Option{FS(fsys)},
+			wantErr: true,
+		},
+		{
+			name:    "invalid include",
+			input:   `{"include":"invalid.json"}`,
+			opts:    []Option{FS(fsys)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := strings.NewReader(tt.input)
+			obj, err := Include(r, []byte(tt.input), decodeJSON, NewOptions(tt.opts...))
+
+			if tt.wantErr && err == nil {
+				assert.NotNil(t, err)
+				return
+			}
+
+			if !tt.wantErr {
+				if err != nil {
+					assert.Nil(t, err)
+					return
+				}
+
+				data, err := obj.MarshalJSON()
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expect, string(data))
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package json
 
 import (
+	"bytes"
+	"encoding/json"
 	"github.com/quen2404/polluter/parser"
 	"io"
 	"io/ioutil"
@@ -9,7 +11,9 @@ import (
 	"github.com/romanyx/jwalk"
 )
 
-type jsonParser struct{}
+type jsonParser struct {
+	opts parser.Options
+}
 
 func (p jsonParser) Parse(r io.Reader) (jwalk.ObjectWalker, error) {
 	data, err := ioutil.ReadAll(r)
@@ -17,6 +21,10 @@ func (p jsonParser) Parse(r io.Reader) (jwalk.ObjectWalker, error) {
 		return nil, errors.Wrap(err, "failed to read")
 	}
 
+	return parser.Include(r, data, decode, p.opts)
+}
+
+func decode(data []byte) (jwalk.ObjectWalker, error) {
 	i, err := jwalk.Parse(data)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to parse")
@@ -30,8 +38,28 @@ func (p jsonParser) Parse(r io.Reader) (jwalk.ObjectWalker, error) {
 	return obj, nil
 }
 
+// Encode writes obj as indented JSON.
+func (p jsonParser) Encode(w io.Writer, obj jwalk.ObjectWalker) error {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return errors.Wrap(err, "marshal failed")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := json.Indent(buf, data, "", "  "); err != nil {
+		return errors.Wrap(err, "indent failed")
+	}
+	buf.WriteString("\n")
+
+	_, err = buf.WriteTo(w)
+	return err
+}
+
 // JSONParser option enables JSON
-// parsing engine for seeding.
-func JSONParser() parser.Parser {
-	return jsonParser{}
+// parsing engine for seeding. Fixtures
+// may include others, see parser.Include.
+func JSONParser(opts ...parser.Option) parser.Parser {
+	return jsonParser{
+		opts: parser.NewOptions(opts...),
+	}
 }
@@ -1,6 +1,7 @@
 package json
 
 import (
+	"bytes"
 	"io"
 	"strings"
 	"testing"
@@ -55,3 +56,30 @@ func Test_jsonParser_parse(t *testing.T) {
 		})
 	}
 }
+
+func Test_jsonParser_Encode(t *testing.T) {
+	input := `{"users":[{"id":1,"name":"Roman","score":1.5}],"key":"value"}`
+
+	p := jsonParser{}
+	w, err := p.Parse(strings.NewReader(input))
+	if err != nil {
+		assert.Nil(t, err)
+		return
+	}
+
+	buf := new(bytes.Buffer)
+	assert.Nil(t, p.Encode(buf, w))
+
+	expect := `{
+  "users": [
+    {
+      "id": 1,
+      "name": "Roman",
+      "score": 1.5
+    }
+  ],
+  "key": "value"
+}
+`
+	assert.Equal(t, expect, buf.String())
+}
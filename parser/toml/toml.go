@@ -0,0 +1,218 @@
+package toml
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/quen2404/polluter/parser"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	"github.com/romanyx/jwalk"
+)
+
+type tomlParser struct{}
+
+func (p tomlParser) Parse(r io.Reader) (jwalk.ObjectWalker, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read from input")
+	}
+
+	j, err := tomlToJSON(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed convert to json")
+	}
+
+	i, err := jwalk.Parse(j)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse")
+	}
+
+	obj, ok := i.(jwalk.ObjectWalker)
+	if !ok {
+		return nil, errors.New("unexpected format")
+	}
+
+	return obj, nil
+}
+
+func tomlToJSON(data []byte) ([]byte, error) {
+	doc := make(map[string]interface{})
+
+	md, err := toml.Decode(string(data), &doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode failed")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := handleMap(doc, "", keyOrder(md), buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// keyOrder recovers the file order of keys, which decoding into
+// maps loses. Every table gets a location: its dotted path, where
+// each array of tables segment is suffixed with the index of the
+// element, e.g. "users#1" for the second [[users]] table.
+func keyOrder(md toml.MetaData) map[string][]string {
+	order := make(map[string][]string)
+	seen := make(map[string]bool)
+	elements := make(map[string]int)
+
+	for _, key := range md.Keys() {
+		if md.Type(key...) == "ArrayHash" {
+			elements[key.String()]++
+		}
+
+		parent := ""
+		for i := range key[:len(key)-1] {
+			parent = location(parent, key[i], elements[toml.Key(key[:i+1]).String()])
+		}
+
+		name := key[len(key)-1]
+		if seen[parent+"."+name] {
+			continue
+		}
+		seen[parent+"."+name] = true
+		order[parent] = append(order[parent], name)
+	}
+
+	return order
+}
+
+func location(parent, name string, elements int) string {
+	loc := name
+	if parent != "" {
+		loc = parent + "." + name
+	}
+	if elements > 0 {
+		loc = loc + "#" + strconv.Itoa(elements-1)
+	}
+	return loc
+}
+
+func handleMap(m map[string]interface{}, loc string, order map[string][]string, buf *bytes.Buffer) error {
+	buf.WriteString("{")
+	for i, key := range sortedKeys(m, order[loc]) {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		name, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		buf.Write(name)
+		buf.WriteString(":")
+
+		child := key
+		if loc != "" {
+			child = loc + "." + key
+		}
+		if err := handleValue(m[key], child, order, buf); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("}")
+
+	return nil
+}
+
+func handleValue(v interface{}, loc string, order map[string][]string, buf *bytes.Buffer) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return handleMap(v, loc, order, buf)
+	case []map[string]interface{}:
+		buf.WriteString("[")
+		for i, m := range v {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			if err := handleMap(m, loc+"#"+strconv.Itoa(i), order, buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	case []interface{}:
+		buf.WriteString("[")
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+			// Inline tables of an array are not told apart
+			// by the metadata, so they share a single order.
+			if err := handleValue(item, loc, order, buf); err != nil {
+				return err
+			}
+		}
+		buf.WriteString("]")
+	case time.Time:
+		return handleValue(formatTime(v), loc, order, buf)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+
+	return nil
+}
+
+// sortedKeys returns keys of m in file order. Keys the
+// metadata knows nothing about are appended sorted.
+func sortedKeys(m map[string]interface{}, order []string) []string {
+	keys := make([]string, 0, len(m))
+	for _, key := range order {
+		if _, ok := m[key]; ok {
+			keys = append(keys, key)
+		}
+	}
+
+	rest := make([]string, 0)
+	for key := range m {
+		if !contains(order, key) {
+			rest = append(rest, key)
+		}
+	}
+	sort.Strings(rest)
+
+	return append(keys, rest...)
+}
+
+func contains(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// formatTime keeps local dates and times the way
+// they were written instead of forcing a zone.
+func formatTime(t time.Time) string {
+	switch t.Location().String() {
+	case "date-local":
+		return t.Format("2006-01-02")
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	default:
+		return t.Format(time.RFC3339Nano)
+	}
+}
+
+// TOMLParser option enables TOML
+// parsing engine for seeding.
+func TOMLParser() parser.Parser {
+	return tomlParser{}
+}
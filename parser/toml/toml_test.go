@@ -0,0 +1,90 @@
+package toml
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	tomlInput = `c = "c"
+b = "b"
+
+[[users]]
+name = "Roman"
+id = 1
+
+[[users]]
+id = 2
+name = "Dmitry"
+born = 1990-01-02
+
+[[roles]]
+id = 1
+name = "User"
+`
+)
+
+func Test_tomlParser_parse(t *testing.T) {
+	tests := []struct {
+		name    string
+		arg     io.Reader
+		order   []string
+		expect  string
+		wantErr bool
+	}{
+		{
+			name: "valid input",
+			arg:  strings.NewReader(tomlInput),
+			order: []string{
+				"c",
+				"b",
+				"users",
+				"roles",
+			},
+			expect: `{"c":"c","b":"b","users":[{"name":"Roman","id":1},{"id":2,"name":"Dmitry","born":"1990-01-02"}],"roles":[{"id":1,"name":"User"}]}`,
+		},
+		{
+			name:    "invalid input",
+			arg:     strings.NewReader(`[[users]`),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := tomlParser{}
+			w, err := p.Parse(tt.arg)
+
+			if tt.wantErr && err == nil {
+				assert.NotNil(t, err)
+				return
+			}
+
+			if !tt.wantErr {
+				if err != nil {
+					assert.Nil(t, err)
+					return
+				}
+
+				var gotOrder []string
+
+				w.Walk(func(key string, _ interface{}) error {
+					gotOrder = append(gotOrder, key)
+					return nil
+				})
+
+				assert.Equal(t, tt.order, gotOrder)
+
+				data, err := w.MarshalJSON()
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expect, string(data))
+			}
+		})
+	}
+}
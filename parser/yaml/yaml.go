@@ -2,18 +2,22 @@ package yaml
 
 import (
 	"bytes"
-	"fmt"
+	"encoding/json"
 	"github.com/quen2404/polluter/parser"
 	"io"
 	"io/ioutil"
+	"sort"
 	"strconv"
 
 	"github.com/pkg/errors"
 	"github.com/romanyx/jwalk"
 	yaml "gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
-type yamlParser struct{}
+type yamlParser struct {
+	opts parser.Options
+}
 
 func (p yamlParser) Parse(r io.Reader) (jwalk.ObjectWalker, error) {
 	data, err := ioutil.ReadAll(r)
@@ -21,6 +25,10 @@ func (p yamlParser) Parse(r io.Reader) (jwalk.ObjectWalker, error) {
 		return nil, errors.Wrap(err, "read from input")
 	}
 
+	return parser.Include(r, data, decode, p.opts)
+}
+
+func decode(data []byte) (jwalk.ObjectWalker, error) {
 	j, err := yamlToJSON(data)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed convert to json")
@@ -40,9 +48,14 @@ func (p yamlParser) Parse(r io.Reader) (jwalk.ObjectWalker, error) {
 }
 
 func yamlToJSON(data []byte) ([]byte, error) {
+	data, err := rawTags(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "unmarshal failed")
+	}
+
 	mapSlice := yaml.MapSlice{}
 
-	err := yaml.Unmarshal(data, &mapSlice)
+	err = yaml.Unmarshal(data, &mapSlice)
 	if err != nil {
 		return nil, errors.Wrap(err, "unmarshal failed")
 	}
@@ -53,6 +66,60 @@ func yamlToJSON(data []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// rawTags rewrites values tagged !sql or
+// !default to the objects SQL engines read
+// raw values from, see polluter.SQLKey:
+//
+//	created_at: !sql now()
+//	status: !default
+func rawTags(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte("!sql")) && !bytes.Contains(data, []byte("!default")) {
+		return data, nil
+	}
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if !rewriteTags(&doc) {
+		return data, nil
+	}
+
+	return yamlv3.Marshal(&doc)
+}
+
+func rewriteTags(n *yamlv3.Node) bool {
+	changed := false
+	for _, c := range n.Content {
+		if rewriteTags(c) {
+			changed = true
+		}
+	}
+	if n.Kind != yamlv3.ScalarNode {
+		return changed
+	}
+
+	var key, value *yamlv3.Node
+	switch n.Tag {
+	case "!sql":
+		key = &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "$sql"}
+		value = &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: n.Value}
+	case "!default":
+		key = &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "$default"}
+		value = &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!bool", Value: "true"}
+	default:
+		return changed
+	}
+
+	*n = yamlv3.Node{
+		Kind:    yamlv3.MappingNode,
+		Tag:     "!!map",
+		Style:   yamlv3.FlowStyle,
+		Content: []*yamlv3.Node{key, value},
+	}
+	return true
+}
+
 func handleMapSlice(mapSlice yaml.MapSlice, buf *bytes.Buffer) {
 	buf.WriteString("{")
 	first := true
@@ -95,7 +162,8 @@ func handleMapSlice(mapSlice yaml.MapSlice, buf *bytes.Buffer) {
 func formatValue(typedYAMLObj interface{}) string {
 	switch typedVal := typedYAMLObj.(type) {
 	case string:
-		return fmt.Sprintf("\"%s\"", typedVal)
+		data, _ := json.Marshal(typedVal)
+		return string(data)
 	case int:
 		return strconv.FormatInt(int64(typedVal), 10)
 	case int64:
@@ -112,12 +180,109 @@ func formatValue(typedYAMLObj interface{}) string {
 	default:
 		return "null"
 	}
+}
+
+// Encode writes obj as YAML.
+func (p yamlParser) Encode(w io.Writer, obj jwalk.ObjectWalker) error {
+	m, err := walkerToMapSlice(obj)
+	if err != nil {
+		return errors.Wrap(err, "convert to yaml")
+	}
 
-	return ""
+	data, err := yaml.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "marshal failed")
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func walkerToMapSlice(obj jwalk.ObjectWalker) (yaml.MapSlice, error) {
+	mapSlice := yaml.MapSlice{}
+
+	err := obj.Walk(func(key string, value interface{}) error {
+		var (
+			v   interface{}
+			err error
+		)
+
+		switch value := value.(type) {
+		case jwalk.ObjectWalker:
+			v, err = walkerToMapSlice(value)
+		case jwalk.ObjectsWalker:
+			items := make([]interface{}, 0)
+			err = value.Walk(func(obj jwalk.ObjectWalker) error {
+				item, err := walkerToMapSlice(obj)
+				items = append(items, item)
+				return err
+			})
+			v = items
+		case json.Marshaler:
+			var data []byte
+			if data, err = value.MarshalJSON(); err == nil {
+				v, err = jsonToYAMLValue(data)
+			}
+		}
+		if err != nil {
+			return errors.Wrap(err, key)
+		}
+
+		mapSlice = append(mapSlice, yaml.MapItem{Key: key, Value: v})
+		return nil
+	})
+
+	return mapSlice, err
+}
+
+func jsonToYAMLValue(data []byte) (interface{}, error) {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	return yamlValue(v), nil
+}
+
+func yamlValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case []interface{}:
+		for i := range v {
+			v[i] = yamlValue(v[i])
+		}
+		return v
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		m := make(yaml.MapSlice, 0, len(keys))
+		for _, k := range keys {
+			m = append(m, yaml.MapItem{Key: k, Value: yamlValue(v[k])})
+		}
+		return m
+	default:
+		return v
+	}
 }
 
 // YAMLParser option enables YAML
-// parsing engine for seeding.
-func YAMLParser() parser.Parser {
-	return yamlParser{}
+// parsing engine for seeding. Fixtures
+// may include others, see parser.Include.
+func YAMLParser(opts ...parser.Option) parser.Parser {
+	return yamlParser{
+		opts: parser.NewOptions(opts...),
+	}
 }
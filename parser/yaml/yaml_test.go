@@ -1,10 +1,15 @@
 package yaml
 
 import (
+	"bytes"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/romanyx/jwalk"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -68,3 +73,83 @@ func Test_yamlParser_parse(t *testing.T) {
 		})
 	}
 }
+
+func Test_yamlParser_include(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "base.yml"), []byte("roles:\n- name: User\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "users.yml"), []byte("include: base.yml\nusers:\n- name: Roman\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "users.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	w, err := YAMLParser().Parse(f)
+	if err != nil {
+		assert.Nil(t, err)
+		return
+	}
+
+	data, err := w.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"roles":[{"name":"User"}],"users":[{"name":"Roman"}]}`, string(data))
+}
+
+func Test_yamlParser_rawTags(t *testing.T) {
+	input := `users:
+- id: 1
+  name: ~
+  note: 'say "hi"'
+  created_at: !sql now() - interval '1 day'
+  status: !default
+`
+
+	w, err := YAMLParser().Parse(strings.NewReader(input))
+	if err != nil {
+		assert.Nil(t, err)
+		return
+	}
+
+	data, err := w.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"users":[{"id":1,"name":null,"note":"say \"hi\"","created_at":{"$sql":"now() - interval '1 day'"},"status":{"$default":true}}]}`, string(data))
+}
+
+func Test_yamlParser_Encode(t *testing.T) {
+	input := `{"users":[{"id":1,"name":"Roman","score":1.5,"tags":{"b":true,"a":null}}],"key":"value"}`
+
+	w, err := jwalk.Parse([]byte(input))
+	if err != nil {
+		assert.Nil(t, err)
+		return
+	}
+
+	p := yamlParser{}
+	buf := new(bytes.Buffer)
+	assert.Nil(t, p.Encode(buf, w.(jwalk.ObjectWalker)))
+
+	expect := `users:
+- id: 1
+  name: Roman
+  score: 1.5
+  tags:
+    b: true
+    a: null
+key: value
+`
+	assert.Equal(t, expect, buf.String())
+
+	back, err := p.Parse(strings.NewReader(buf.String()))
+	if err != nil {
+		assert.Nil(t, err)
+		return
+	}
+	data, err := back.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, input, string(data))
+}
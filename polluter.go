@@ -1,11 +1,15 @@
 package polluter
 
 import (
+	"context"
 	"github.com/quen2404/polluter/parser"
 	"io"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/romanyx/jwalk"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type (
@@ -13,11 +17,48 @@ type (
 		Exec(Commands) error
 	}
 
+	// ContextExecer is implemented by engines
+	// which can honour a context while executing.
+	ContextExecer interface {
+		ExecContext(context.Context, Commands) error
+	}
+
+	// ContextBuilder is implemented by engines
+	// which query the database while building.
+	ContextBuilder interface {
+		BuildContext(context.Context, jwalk.ObjectWalker) (Commands, error)
+	}
+
+	// Checker is implemented by engines which can
+	// read back what a fixture describes, see Verify.
+	Checker interface {
+		// Fetch returns the current rows of the
+		// tables and values of the keys of obj.
+		// Rows hold at least the columns found
+		// in obj. Keys which do not exist are
+		// left out.
+		Fetch(context.Context, jwalk.ObjectWalker) (Record, error)
+	}
+
+	// Reverter is implemented by engines which can
+	// undo the seeding of a fixture, deleting the
+	// rows of its tables and its keys. Rows are
+	// found by their id or _id column, or by all
+	// their columns otherwise. Engines refuse to
+	// revert what they may have overwritten.
+	Reverter interface {
+		Revert(context.Context, jwalk.ObjectWalker) error
+	}
+
 	Commands []Command
 
 	Command struct {
 		Q    string
 		Args []interface{}
+		// Table is the table, collection or key
+		// the command writes Rows to, if known.
+		Table string
+		Rows  int
 	}
 
 	Builder interface {
@@ -35,29 +76,196 @@ type (
 	Polluter struct {
 		DbEngine
 		parser.Parser
+
+		template    *templateConfig
+		interpolate bool
+		vars        map[string]interface{}
+		generators  generators
+		factories   map[string]Factory
+		truncate    bool
+		hooks       hooks
+		logger      *CommandLogger
+		tracer      trace.Tracer
+		schemas     *SchemaValidator
+		snapshotter Snapshotter
 	}
+
+	// Option configures Polluter.
+	Option func(*Polluter)
 )
 
 // Pollute parses input from the reader and
 // tries to exec generated commands on a database.
 // Use New factory function to generate.
 func (p *Polluter) Pollute(r io.Reader) error {
+	return p.PolluteContext(context.Background(), r)
+}
+
+// PolluteContext is Pollute honouring ctx, which
+// is passed to engines implementing ContextExecer
+// and ContextBuilder.
+func (p *Polluter) PolluteContext(ctx context.Context, r io.Reader) (err error) {
+	ctx, _, end := p.span(ctx, "polluter.Pollute")
+	defer end(&err)
+
+	obj, err := p.parse(ctx, r)
+	if err != nil {
+		return err
+	}
+	if p.truncate {
+		if err := p.truncateTables(ctx, obj); err != nil {
+			return errors.Wrap(err, "truncate failed")
+		}
+	}
+
+	return p.pollute(ctx, obj)
+}
+
+func (p *Polluter) parse(ctx context.Context, r io.Reader) (_ jwalk.ObjectWalker, err error) {
+	ctx, _, end := p.span(ctx, "polluter.parse")
+	defer end(&err)
+
+	if r, err = p.hooks.beforeParse(ctx, r); err != nil {
+		return nil, errors.Wrap(err, "parse hook failed")
+	}
+
+	if p.template != nil {
+		rendered, err := p.template.render(r)
+		if err != nil {
+			return nil, errors.Wrap(err, "render failed")
+		}
+		r = rendered
+	}
+
 	obj, err := p.Parser.Parse(r)
 	if err != nil {
-		return errors.Wrap(err, "parse failed")
+		return nil, errors.Wrap(err, "parse failed")
 	}
 
-	commands, err := p.DbEngine.Build(obj)
+	obj, err = p.hooks.afterParse(ctx, obj)
+	return obj, errors.Wrap(err, "parse hook failed")
+}
+
+func (p *Polluter) pollute(ctx context.Context, obj jwalk.ObjectWalker) error {
+	obj, commands, err := p.build(ctx, obj)
 	if err != nil {
-		return errors.Wrap(err, "Build commands failed")
+		return err
 	}
-	if err := p.DbEngine.Exec(commands); err != nil {
+	if err := p.exec(ctx, commands); err != nil {
 		return errors.Wrap(err, "exec failed")
 	}
 
 	return nil
 }
 
+func (p *Polluter) build(ctx context.Context, obj jwalk.ObjectWalker) (_ jwalk.ObjectWalker, _ Commands, err error) {
+	ctx, span, end := p.span(ctx, "polluter.build")
+	defer end(&err)
+
+	obj, err = p.prepare(ctx, obj)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commands, err := p.buildEngine(ctx, obj)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Build commands failed")
+	}
+	if commands, err = p.hooks.afterBuild(ctx, commands); err != nil {
+		return nil, nil, errors.Wrap(err, "build hook failed")
+	}
+
+	span.SetAttributes(attribute.Int("polluter.commands", len(commands)))
+	return obj, commands, nil
+}
+
+func (p *Polluter) exec(ctx context.Context, commands Commands) (err error) {
+	ctx, _, end := p.span(ctx, "polluter.exec", attribute.Int("polluter.commands", len(commands)))
+	defer end(&err)
+
+	ctx = p.logger.Context(p.hooks.context(ctx))
+	if p.tracer != nil {
+		ctx = context.WithValue(ctx, tracerKey{}, p.tracer)
+	}
+	if p.logger == nil {
+		return p.execEngine(ctx, commands)
+	}
+
+	start := time.Now()
+	if err := p.execEngine(ctx, commands); err != nil {
+		return err
+	}
+	p.logger.logger.Printf("polluter: executed %d command(s) in %s", len(commands), time.Since(start))
+	return nil
+}
+
+func (p *Polluter) buildEngine(ctx context.Context, obj jwalk.ObjectWalker) (Commands, error) {
+	if e, ok := p.DbEngine.(ContextBuilder); ok {
+		return e.BuildContext(ctx, obj)
+	}
+	return p.DbEngine.Build(obj)
+}
+
+func (p *Polluter) execEngine(ctx context.Context, commands Commands) error {
+	if e, ok := p.DbEngine.(ContextExecer); ok {
+		return e.ExecContext(ctx, commands)
+	}
+	return p.DbEngine.Exec(commands)
+}
+
+// prepare rewrites records before commands
+// are built: it applies enabled options,
+// evaluates generator expressions, calls
+// build hooks and checks JSON Schemas.
+func (p *Polluter) prepare(ctx context.Context, obj jwalk.ObjectWalker) (jwalk.ObjectWalker, error) {
+	doc, err := p.records(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc, err = p.generators.generate(doc); err != nil {
+		return nil, errors.Wrap(err, "generate failed")
+	}
+
+	if doc, err = p.hooks.beforeBuild(ctx, doc); err != nil {
+		return nil, errors.Wrap(err, "build hook failed")
+	}
+
+	if p.schemas != nil {
+		if err := p.schemas.validate(doc); err != nil {
+			return nil, errors.Wrap(err, "schema validation failed")
+		}
+	}
+	if p.tracer != nil {
+		traceTables(trace.SpanFromContext(ctx), doc)
+	}
+
+	return doc.Walker()
+}
+
+// records converts obj to records with variables
+// interpolated and factories applied.
+func (p *Polluter) records(obj jwalk.ObjectWalker) (Record, error) {
+	doc, err := RecordOf(obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "read records")
+	}
+
+	if p.interpolate {
+		if doc, err = interpolateRecord(doc, p.lookup, p.generators.on()); err != nil {
+			return nil, errors.Wrap(err, "interpolate failed")
+		}
+	}
+
+	if len(p.factories) > 0 {
+		if doc, err = p.applyFactories(doc); err != nil {
+			return nil, errors.Wrap(err, "factories failed")
+		}
+	}
+
+	return doc, nil
+}
+
 // New factory method returns initialized
 // Polluter.
 // For example to seed MySQL database with
@@ -66,9 +274,13 @@ func (p *Polluter) Pollute(r io.Reader) error {
 // To seed Postgres database with YAML input
 // use:
 // 		p := New(PostgresEngine(db), YAMLParser)
-func New(engine DbEngine, parser parser.Parser) *Polluter {
-	return &Polluter{
+func New(engine DbEngine, parser parser.Parser, opts ...Option) *Polluter {
+	p := &Polluter{
 		Parser:   parser,
 		DbEngine: engine,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
@@ -0,0 +1,41 @@
+// Package polluttest holds helpers to use
+// polluter from tests, kept apart so that
+// the testing package is not linked into
+// programs which seed databases.
+package polluttest
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/quen2404/polluter"
+)
+
+type tbLogger struct {
+	t testing.TB
+}
+
+// Logger returns a polluter.Logger writing
+// to the log of a test, shown when it fails
+// or with go test -v.
+func Logger(t testing.TB) polluter.Logger {
+	return tbLogger{t}
+}
+
+func (l tbLogger) Printf(format string, v ...interface{}) {
+	l.t.Helper()
+	l.t.Logf(format, v...)
+}
+
+// Assert verifies the database against the
+// fixture with p and reports differences to t.
+func Assert(t testing.TB, p *polluter.Polluter, r io.Reader) bool {
+	t.Helper()
+
+	if err := p.Verify(context.Background(), r); err != nil {
+		t.Errorf("verify failed: %s", err)
+		return false
+	}
+	return true
+}
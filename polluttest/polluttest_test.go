@@ -0,0 +1,91 @@
+package polluttest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/quen2404/polluter/polluttest"
+	"github.com/romanyx/jwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+type checkerEngine struct {
+	state polluter.Record
+}
+
+func (e checkerEngine) Build(jwalk.ObjectWalker) (polluter.Commands, error) {
+	return nil, nil
+}
+
+func (e checkerEngine) Exec(polluter.Commands) error {
+	return nil
+}
+
+func (e checkerEngine) Fetch(context.Context, jwalk.ObjectWalker) (polluter.Record, error) {
+	return e.state, nil
+}
+
+type fakeTB struct {
+	testing.TB
+	errors []string
+	logs   []string
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Logf(format string, args ...interface{}) {
+	tb.logs = append(tb.logs, format)
+}
+
+func (tb *fakeTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, format)
+}
+
+const input = `users:
+- id: 1
+  name: Roman
+`
+
+func TestAssert(t *testing.T) {
+	tests := []struct {
+		name   string
+		state  polluter.Record
+		expect bool
+	}{
+		{
+			name: "matching",
+			state: polluter.Record{
+				{Name: "users", Value: []polluter.Record{
+					{{Name: "id", Value: int64(1)}, {Name: "name", Value: "Roman"}},
+				}},
+			},
+			expect: true,
+		},
+		{
+			name:  "missing row",
+			state: polluter.Record{{Name: "users", Value: []polluter.Record{}}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := polluter.New(checkerEngine{state: tt.state}, yaml.YAMLParser())
+			tb := new(fakeTB)
+
+			assert.Equal(t, tt.expect, polluttest.Assert(tb, p, strings.NewReader(input)))
+			assert.Equal(t, !tt.expect, len(tb.errors) == 1)
+		})
+	}
+}
+
+func TestLogger(t *testing.T) {
+	tb := new(fakeTB)
+	polluttest.Logger(tb).Printf("polluter: %s", "SELECT 1;")
+	assert.Equal(t, []string{"polluter: %s"}, tb.logs)
+}
@@ -0,0 +1,62 @@
+package polluter
+
+import "github.com/romanyx/jwalk"
+
+// SQLKey and DefaultKey are the keys of the
+// objects fixtures write raw SQL values with:
+//
+//	{"created_at": {"$sql": "now()"}, "status": {"$default": true}}
+//
+// SQL engines emit the expression, or DEFAULT,
+// instead of binding a parameter. In YAML, the
+// !sql and !default tags stand for them.
+const (
+	SQLKey     = "$sql"
+	DefaultKey = "$default"
+)
+
+// SQL returns the fixture value of a raw
+// SQL expression, for rows given as Go
+// values.
+func SQL(expr string) Record {
+	return Record{{Name: SQLKey, Value: expr}}
+}
+
+// Default returns the fixture value
+// of the default of a column.
+func Default() Record {
+	return Record{{Name: DefaultKey, Value: true}}
+}
+
+// RawSQL returns the SQL expression a fixture
+// value stands for, DEFAULT included, when it
+// is a Record or a walker of a single $sql or
+// $default field.
+func RawSQL(v interface{}) (string, bool) {
+	var r Record
+	switch v := v.(type) {
+	case Record:
+		r = v
+	case jwalk.ObjectWalker:
+		var err error
+		if r, err = RecordOf(v); err != nil {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	if len(r) != 1 {
+		return "", false
+	}
+
+	switch f := r[0]; f.Name {
+	case SQLKey:
+		expr, ok := f.Value.(string)
+		return expr, ok && expr != ""
+	case DefaultKey:
+		if f.Value == true {
+			return "DEFAULT", true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,55 @@
+package polluter_test
+
+import (
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawSQL(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		expect string
+		ok     bool
+	}{
+		{name: "expression", value: polluter.SQL("now()"), expect: "now()", ok: true},
+		{name: "default", value: polluter.Default(), expect: "DEFAULT", ok: true},
+		{name: "default disabled", value: polluter.Record{{Name: polluter.DefaultKey, Value: false}}},
+		{name: "empty expression", value: polluter.SQL("")},
+		{name: "other object", value: polluter.Record{{Name: polluter.SQLKey, Value: "now()"}, {Name: "id", Value: 1}}},
+		{name: "string", value: "now()"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			expr, ok := polluter.RawSQL(tt.value)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expect, expr)
+
+			if w, err := (polluter.Record{{Name: "v", Value: tt.value}}).Walker(); assert.Nil(t, err) {
+				r, err := polluter.RecordOf(w)
+				assert.Nil(t, err)
+				expr, ok = polluter.RawSQL(r[0].Value)
+				assert.Equal(t, tt.ok, ok)
+			}
+		})
+	}
+}
+
+func TestCompare_raw(t *testing.T) {
+	expected := polluter.Record{{Name: "users", Value: []polluter.Record{{
+		{Name: "id", Value: 1},
+		{Name: "created_at", Value: polluter.SQL("now()")},
+	}}}}
+	actual := polluter.Record{{Name: "users", Value: []polluter.Record{{
+		{Name: "id", Value: 1},
+		{Name: "created_at", Value: "2020-01-02T03:04:05Z"},
+	}}}}
+
+	assert.Empty(t, polluter.Compare(expected, actual))
+}
@@ -0,0 +1,213 @@
+package polluter
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/romanyx/jwalk"
+)
+
+type (
+	// Record is a fixture object which keeps
+	// its fields in the order they were given.
+	// Values are nil, bool, string, json.Number,
+	// Record, []Record, []interface{} or any
+	// other value encoding/json can marshal.
+	Record []Field
+
+	// Field is a named value of a Record.
+	Field struct {
+		Name  string
+		Value interface{}
+	}
+)
+
+// Get returns the value of the field with
+// the given name.
+func (r Record) Get(name string) (interface{}, bool) {
+	for _, f := range r {
+		if f.Name == name {
+			return f.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set replaces the value of the field with the
+// given name or appends the field if missing.
+func (r Record) Set(name string, value interface{}) Record {
+	for i, f := range r {
+		if f.Name == name {
+			r[i].Value = value
+			return r
+		}
+	}
+	return append(r, Field{Name: name, Value: value})
+}
+
+// Delete removes the field with the given name.
+func (r Record) Delete(name string) Record {
+	for i, f := range r {
+		if f.Name == name {
+			return append(r[:i:i], r[i+1:]...)
+		}
+	}
+	return r
+}
+
+// MarshalJSON encodes the record as a JSON
+// object keeping the order of the fields.
+func (r Record) MarshalJSON() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	buf.WriteString("{")
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		name, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteString(":")
+
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "marshal %s", f.Name)
+		}
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+
+	return buf.Bytes(), nil
+}
+
+// Walker converts the record to the walker
+// database engines build commands from.
+func (r Record) Walker() (jwalk.ObjectWalker, error) {
+	data, err := r.MarshalJSON()
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal record")
+	}
+
+	i, err := jwalk.Parse(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse")
+	}
+
+	obj, ok := i.(jwalk.ObjectWalker)
+	if !ok {
+		return nil, errors.New("unexpected format")
+	}
+
+	return obj, nil
+}
+
+// RecordOf converts a walker produced by a
+// parser to a Record which can be modified.
+func RecordOf(obj jwalk.ObjectWalker) (Record, error) {
+	r := make(Record, 0)
+
+	if err := obj.Walk(func(name string, value interface{}) error {
+		v, err := valueOf(value)
+		if err != nil {
+			return errors.Wrap(err, name)
+		}
+		r = append(r, Field{Name: name, Value: v})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func valueOf(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case jwalk.ObjectWalker:
+		return RecordOf(v)
+	case jwalk.ObjectsWalker:
+		records := make([]Record, 0)
+		if err := v.Walk(func(obj jwalk.ObjectWalker) error {
+			r, err := RecordOf(obj)
+			if err != nil {
+				return err
+			}
+			records = append(records, r)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		return records, nil
+	case json.Marshaler:
+		data, err := v.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+
+		// Numbers are kept as json.Number so that
+		// big integers survive the round trip.
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		var i interface{}
+		if err := d.Decode(&i); err != nil {
+			return nil, err
+		}
+		return i, nil
+	default:
+		return nil, errors.Errorf("unexpected value %T", value)
+	}
+}
+
+// mapStrings applies fn to every string
+// found in value, however deep it is.
+func mapStrings(value interface{}, fn func(string) (interface{}, error)) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return fn(v)
+	case Record:
+		res := make(Record, 0, len(v))
+		for _, f := range v {
+			fv, err := mapStrings(f.Value, fn)
+			if err != nil {
+				return nil, errors.Wrap(err, f.Name)
+			}
+			res = append(res, Field{Name: f.Name, Value: fv})
+		}
+		return res, nil
+	case []Record:
+		res := make([]Record, 0, len(v))
+		for i, r := range v {
+			rv, err := mapStrings(r, fn)
+			if err != nil {
+				return nil, errors.Wrapf(err, "[%d]", i)
+			}
+			res = append(res, rv.(Record))
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, 0, len(v))
+		for i, item := range v {
+			iv, err := mapStrings(item, fn)
+			if err != nil {
+				return nil, errors.Wrapf(err, "[%d]", i)
+			}
+			res = append(res, iv)
+		}
+		return res, nil
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			iv, err := mapStrings(item, fn)
+			if err != nil {
+				return nil, errors.Wrap(err, k)
+			}
+			res[k] = iv
+		}
+		return res, nil
+	default:
+		return value, nil
+	}
+}
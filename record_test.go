@@ -0,0 +1,57 @@
+package polluter_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordOf(t *testing.T) {
+	obj, err := yaml.YAMLParser().Parse(strings.NewReader(`users:
+- id: 9007199254740993
+  name: Roman
+  meta:
+    b: 1
+    a: 2
+count: 1
+`))
+	if err != nil {
+		assert.Nil(t, err)
+		return
+	}
+
+	doc, err := polluter.RecordOf(obj)
+	if err != nil {
+		assert.Nil(t, err)
+		return
+	}
+
+	users, ok := doc.Get("users")
+	assert.True(t, ok)
+	assert.Equal(t, []polluter.Record{
+		{
+			{Name: "id", Value: json.Number("9007199254740993")},
+			{Name: "name", Value: "Roman"},
+			{Name: "meta", Value: polluter.Record{
+				{Name: "b", Value: json.Number("1")},
+				{Name: "a", Value: json.Number("2")},
+			}},
+		},
+	}, users)
+
+	doc = doc.Set("count", 2).Set("extra", true).Delete("users")
+
+	w, err := doc.Walker()
+	if err != nil {
+		assert.Nil(t, err)
+		return
+	}
+
+	data, err := w.MarshalJSON()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"count":2,"extra":true}`, string(data))
+}
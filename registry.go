@@ -0,0 +1,101 @@
+package polluter
+
+import (
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// OpenConfig holds the settings of
+	// an engine opened from a DSN.
+	OpenConfig struct {
+		// Upsert makes the engine update
+		// existing rows instead of failing.
+		Upsert bool
+	}
+
+	// OpenOption configures Open.
+	OpenOption func(*OpenConfig)
+
+	// EngineFactory connects to the database of
+	// dsn and returns its engine along with the
+	// connection, which is closed by the caller.
+	EngineFactory func(dsn string, cfg OpenConfig) (DbEngine, io.Closer, error)
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]EngineFactory)
+)
+
+// Register makes an engine available to Open
+// for DSNs with the given URL scheme. Database
+// packages register themselves when imported.
+// It panics if the scheme is registered twice.
+func Register(scheme string, factory EngineFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if factory == nil {
+		panic("polluter: Register factory is nil")
+	}
+	if _, dup := registry[scheme]; dup {
+		panic("polluter: Register called twice for scheme " + scheme)
+	}
+	registry[scheme] = factory
+}
+
+// Schemes returns the sorted list
+// of registered schemes.
+func Schemes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	schemes := make([]string, 0, len(registry))
+	for s := range registry {
+		schemes = append(schemes, s)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Open returns the engine registered for the
+// scheme of dsn, e.g. postgres://, mysql://,
+// mongodb:// or redis://, connected to its
+// database. The database package must be
+// imported, for its side effect at least:
+//
+//	import _ "github.com/quen2404/polluter/database/postgres"
+func Open(dsn string, opts ...OpenOption) (DbEngine, io.Closer, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parse dsn")
+	}
+
+	registryMu.RLock()
+	factory, ok := registry[u.Scheme]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, nil, errors.Errorf("unknown scheme %q, registered: %s", u.Scheme, strings.Join(Schemes(), ", "))
+	}
+
+	var cfg OpenConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return factory(dsn, cfg)
+}
+
+// WithUpsert option opens an engine which
+// updates existing rows instead of failing.
+func WithUpsert() OpenOption {
+	return func(c *OpenConfig) {
+		c.Upsert = true
+	}
+}
@@ -0,0 +1,45 @@
+package polluter_test
+
+import (
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	registerFake sync.Once
+	fakeOpened   = make(chan polluter.OpenConfig, 1)
+)
+
+func TestOpen(t *testing.T) {
+	// Register panics on duplicates, and the
+	// registry outlives runs of go test -count.
+	registerFake.Do(func() {
+		polluter.Register("fake", func(dsn string, cfg polluter.OpenConfig) (polluter.DbEngine, io.Closer, error) {
+			fakeOpened <- cfg
+			return fakeEngine{}, ioutil.NopCloser(nil), nil
+		})
+	})
+
+	assert.Contains(t, polluter.Schemes(), "fake")
+
+	e, c, err := polluter.Open("fake://localhost/test", polluter.WithUpsert())
+	if assert.Nil(t, err) {
+		assert.Equal(t, fakeEngine{}, e)
+		assert.Nil(t, c.Close())
+		assert.True(t, (<-fakeOpened).Upsert)
+	}
+
+	_, _, err = polluter.Open("unknown://localhost")
+	assert.NotNil(t, err)
+
+	assert.Panics(t, func() {
+		polluter.Register("fake", func(string, polluter.OpenConfig) (polluter.DbEngine, io.Closer, error) {
+			return nil, nil, nil
+		})
+	})
+}
@@ -0,0 +1,101 @@
+package polluter
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/romanyx/jwalk"
+)
+
+// Columns returns the columns of rows in order
+// of appearance. Like SQL engines do when they
+// build inserts, fields which are neither plain
+// nor raw SQL values are left out.
+func Columns(rows jwalk.ObjectsWalker) ([]string, error) {
+	columns := make([]string, 0)
+
+	if err := rows.Walk(func(obj jwalk.ObjectWalker) error {
+		return obj.Walk(func(field string, value interface{}) error {
+			_, raw := RawSQL(value)
+			if _, ok := value.(jwalk.Value); (ok || raw) && !containsString(columns, field) {
+				columns = append(columns, field)
+			}
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
+
+// ScanRecords reads all rows into records.
+// Raw bytes are returned as strings, as
+// json.Number for numeric columns, or base64
+// encoded for binary columns, as the Coerce
+// option of SQL engines decodes them.
+func ScanRecords(rows *sql.Rows) ([]Record, error) {
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, errors.Wrap(err, "columns")
+	}
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, errors.Wrap(err, "column types")
+	}
+
+	records := make([]Record, 0)
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, errors.Wrap(err, "scan")
+		}
+
+		r := make(Record, 0, len(columns))
+		for i, c := range columns {
+			v := values[i]
+			if b, ok := v.([]byte); ok {
+				switch typ := types[i].DatabaseTypeName(); {
+				case isNumeric(typ):
+					v = json.Number(b)
+				case isBinary(typ):
+					v = base64.StdEncoding.EncodeToString(b)
+				default:
+					v = string(b)
+				}
+			}
+			r = append(r, Field{Name: c, Value: v})
+		}
+		records = append(records, r)
+	}
+
+	return records, errors.Wrap(rows.Err(), "rows")
+}
+
+func isNumeric(typ string) bool {
+	switch strings.ToUpper(typ) {
+	case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT",
+		"UNSIGNED TINYINT", "UNSIGNED SMALLINT", "UNSIGNED MEDIUMINT", "UNSIGNED INT", "UNSIGNED BIGINT",
+		"INT2", "INT4", "INT8", "DECIMAL", "NUMERIC", "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "REAL":
+		return true
+	}
+	return false
+}
+
+func isBinary(typ string) bool {
+	switch strings.ToUpper(typ) {
+	case "BYTEA", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "IMAGE":
+		return true
+	}
+	return false
+}
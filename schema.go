@@ -0,0 +1,172 @@
+package polluter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/romanyx/jwalk"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// SchemaValidator checks the rows of
+// tables against JSON Schemas.
+type SchemaValidator struct {
+	schemas map[string]*jsonschema.Schema
+}
+
+// NewSchemaValidator compiles the JSON Schemas
+// rows must match, by table name. Schemas may
+// use any draft, the latest one by default.
+func NewSchemaValidator(schemas map[string]io.Reader) (*SchemaValidator, error) {
+	tables := make([]string, 0, len(schemas))
+	for table := range schemas {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	c := jsonschema.NewCompiler()
+	v := &SchemaValidator{schemas: make(map[string]*jsonschema.Schema, len(schemas))}
+	for _, table := range tables {
+		name := "polluter:///" + url.PathEscape(table) + ".json"
+		if err := c.AddResource(name, schemas[table]); err != nil {
+			return nil, errors.Wrapf(err, "schema of %s", table)
+		}
+
+		s, err := c.Compile(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "schema of %s", table)
+		}
+		v.schemas[table] = s
+	}
+
+	return v, nil
+}
+
+// Validate checks the rows of the tables of a
+// parsed fixture which have a schema. Other
+// tables are not checked. Mismatches are
+// reported by a *ValidationError, located by
+// table, row and JSON path in the row.
+func (v *SchemaValidator) Validate(obj jwalk.ObjectWalker) error {
+	doc, err := RecordOf(obj)
+	if err != nil {
+		return errors.Wrap(err, "read records")
+	}
+	return v.validate(doc)
+}
+
+func (v *SchemaValidator) validate(doc Record) error {
+	problems := make([]Problem, 0)
+
+	for _, f := range doc {
+		s, ok := v.schemas[f.Name]
+		if !ok {
+			continue
+		}
+
+		rows, ok := f.Value.([]Record)
+		if !ok {
+			problems = append(problems, Problem{Table: f.Name, Row: -1, Message: "not a list of rows"})
+			continue
+		}
+
+		for i, row := range rows {
+			instance, err := jsonValue(withoutRaw(row))
+			if err != nil {
+				return errors.Wrapf(err, "%s[%d]", f.Name, i)
+			}
+
+			err = s.Validate(instance)
+			var verr *jsonschema.ValidationError
+			if errors.As(err, &verr) {
+				problems = append(problems, rowProblems(f.Name, i, verr)...)
+			} else if err != nil {
+				return errors.Wrapf(err, "%s[%d]", f.Name, i)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// withoutRaw returns row without its raw SQL
+// values, which a schema does not describe.
+func withoutRaw(r Record) Record {
+	res := make(Record, 0, len(r))
+	for _, f := range r {
+		if _, ok := RawSQL(f.Value); !ok {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// jsonValue converts a row to the values
+// encoding/json decodes, numbers kept as
+// json.Number.
+func jsonValue(r Record) (interface{}, error) {
+	data, err := r.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	var v interface{}
+	err = d.Decode(&v)
+	return v, err
+}
+
+// rowProblems returns the problems of a row,
+// sorted by column and message, since causes
+// of properties come in no particular order.
+func rowProblems(table string, row int, err *jsonschema.ValidationError) []Problem {
+	problems := make([]Problem, 0)
+	for _, leaf := range leaves(err) {
+		problems = append(problems, Problem{
+			Table:   table,
+			Row:     row,
+			Column:  strings.ReplaceAll(strings.TrimPrefix(leaf.InstanceLocation, "/"), "/", "."),
+			Message: leaf.Message,
+		})
+	}
+
+	sort.Slice(problems, func(i, j int) bool {
+		if problems[i].Column != problems[j].Column {
+			return problems[i].Column < problems[j].Column
+		}
+		return problems[i].Message < problems[j].Message
+	})
+	return problems
+}
+
+// leaves returns the errors
+// which have no cause.
+func leaves(err *jsonschema.ValidationError) []*jsonschema.ValidationError {
+	if len(err.Causes) == 0 {
+		return []*jsonschema.ValidationError{err}
+	}
+
+	res := make([]*jsonschema.ValidationError, 0, len(err.Causes))
+	for _, c := range err.Causes {
+		res = append(res, leaves(c)...)
+	}
+	return res
+}
+
+// JSONSchema option checks records against
+// the schemas of v once they are prepared,
+// before commands are built.
+func JSONSchema(v *SchemaValidator) Option {
+	return func(p *Polluter) {
+		p.schemas = v
+	}
+}
@@ -0,0 +1,100 @@
+package polluter_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+const usersSchema = `{
+	"type": "object",
+	"required": ["id", "name"],
+	"properties": {
+		"id": {"type": "integer"},
+		"name": {"type": "string", "minLength": 1},
+		"meta": {"type": "object", "properties": {"age": {"type": "integer", "minimum": 0}}},
+		"created_at": {"type": "string"}
+	}
+}`
+
+func TestSchemaValidator_Validate(t *testing.T) {
+	v, err := polluter.NewSchemaValidator(map[string]io.Reader{"users": strings.NewReader(usersSchema)})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		expect []polluter.Problem
+	}{
+		{
+			name:  "valid",
+			input: "users:\n- id: 1\n  name: Roman\nroles:\n- anything: true\n",
+		},
+		{
+			name:  "raw values",
+			input: "users:\n- id: 1\n  name: Roman\n  created_at:\n    $sql: now()\n- id: 2\n  name: Dmitry\n  created_at:\n    $default: true\n",
+		},
+		{
+			name:  "invalid",
+			input: "users:\n- id: 1\n  name: Roman\n- id: 1.5\n  name: Dmitry\n  meta:\n    age: -1\n- id: 3\n",
+			expect: []polluter.Problem{
+				{Table: "users", Row: 1, Column: "id", Message: "expected integer, but got number"},
+				{Table: "users", Row: 1, Column: "meta.age", Message: "must be >= 0 but found -1"},
+				{Table: "users", Row: 2, Column: "", Message: "missing properties: 'name'"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			obj, err := yaml.YAMLParser().Parse(strings.NewReader(tt.input))
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			err = v.Validate(obj)
+			if tt.expect == nil {
+				assert.Nil(t, err)
+				return
+			}
+
+			var verr *polluter.ValidationError
+			if assert.True(t, errors.As(err, &verr), "%v", err) {
+				assert.Equal(t, tt.expect, verr.Problems)
+			}
+		})
+	}
+}
+
+func TestNewSchemaValidator_invalid(t *testing.T) {
+	_, err := polluter.NewSchemaValidator(map[string]io.Reader{"users": strings.NewReader(`{"type": 1}`)})
+	assert.NotNil(t, err)
+}
+
+func TestPolluterJSONSchema(t *testing.T) {
+	v, err := polluter.NewSchemaValidator(map[string]io.Reader{"users": strings.NewReader(usersSchema)})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var executed []polluter.Command
+	p := polluter.New(hookedEngine{executed: &executed}, yaml.YAMLParser(), polluter.JSONSchema(v))
+
+	err = p.Pollute(strings.NewReader("users:\n- id: 1\n"))
+	var verr *polluter.ValidationError
+	assert.True(t, errors.As(err, &verr), "%v", err)
+	assert.Empty(t, executed)
+
+	assert.Nil(t, p.Pollute(strings.NewReader("users:\n- id: 1\n  name: Roman\n")))
+	assert.Len(t, executed, 1)
+}
@@ -0,0 +1,74 @@
+package polluter
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Snapshotter is implemented by engines, or
+// given with the Snapshots option, which save
+// the state of a database under a name and
+// bring it back, like after seeding a baseline
+// once for many tests.
+type Snapshotter interface {
+	Snapshot(ctx context.Context, name string) error
+	Restore(ctx context.Context, name string) error
+	DropSnapshot(ctx context.Context, name string) error
+}
+
+// Snapshots option sets what saves and restores
+// the database, when the engine cannot.
+func Snapshots(s Snapshotter) Option {
+	return func(p *Polluter) {
+		p.snapshotter = s
+	}
+}
+
+func (p *Polluter) snapshots() (Snapshotter, error) {
+	if p.snapshotter != nil {
+		return p.snapshotter, nil
+	}
+	if s, ok := p.DbEngine.(Snapshotter); ok {
+		return s, nil
+	}
+	return nil, errors.New("engine does not support snapshots")
+}
+
+// Snapshot saves the state of the database
+// under name, replacing any snapshot with
+// the same name.
+func (p *Polluter) Snapshot(ctx context.Context, name string) (err error) {
+	ctx, _, end := p.span(ctx, "polluter.Snapshot", attribute.String("polluter.snapshot", name))
+	defer end(&err)
+
+	s, err := p.snapshots()
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(s.Snapshot(ctx, name), "snapshot failed")
+}
+
+// Restore brings the database back to the
+// state saved under name.
+func (p *Polluter) Restore(ctx context.Context, name string) (err error) {
+	ctx, _, end := p.span(ctx, "polluter.Restore", attribute.String("polluter.snapshot", name))
+	defer end(&err)
+
+	s, err := p.snapshots()
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(s.Restore(ctx, name), "restore failed")
+}
+
+// DropSnapshot deletes the snapshot
+// saved under name.
+func (p *Polluter) DropSnapshot(ctx context.Context, name string) error {
+	s, err := p.snapshots()
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(s.DropSnapshot(ctx, name), "drop snapshot failed")
+}
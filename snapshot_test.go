@@ -0,0 +1,60 @@
+package polluter_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+// snapshotterEngine keeps its rows in
+// memory, by snapshot name.
+type snapshotterEngine struct {
+	fakeEngine
+	state     *[]string
+	snapshots map[string][]string
+}
+
+func (e snapshotterEngine) Snapshot(_ context.Context, name string) error {
+	e.snapshots[name] = append([]string(nil), *e.state...)
+	return nil
+}
+
+func (e snapshotterEngine) Restore(_ context.Context, name string) error {
+	*e.state = append([]string(nil), e.snapshots[name]...)
+	return nil
+}
+
+func (e snapshotterEngine) DropSnapshot(_ context.Context, name string) error {
+	delete(e.snapshots, name)
+	return nil
+}
+
+func TestPolluterSnapshot(t *testing.T) {
+	ctx := context.Background()
+	state := []string{"baseline"}
+	e := snapshotterEngine{state: &state, snapshots: make(map[string][]string)}
+
+	for _, p := range []*polluter.Polluter{
+		polluter.New(e, yaml.YAMLParser()),
+		polluter.New(fakeEngine{}, yaml.YAMLParser(), polluter.Snapshots(e)),
+	} {
+		state = []string{"baseline"}
+		assert.Nil(t, p.Snapshot(ctx, "base"))
+
+		state = append(state, "test")
+		assert.Nil(t, p.Restore(ctx, "base"))
+		assert.Equal(t, []string{"baseline"}, state)
+
+		assert.Nil(t, p.DropSnapshot(ctx, "base"))
+		assert.Empty(t, e.snapshots)
+	}
+}
+
+func TestPolluterSnapshot_unsupported(t *testing.T) {
+	p := polluter.New(fakeEngine{}, yaml.YAMLParser())
+	assert.NotNil(t, p.Snapshot(context.Background(), "base"))
+	assert.NotNil(t, p.Restore(context.Background(), "base"))
+}
@@ -0,0 +1,213 @@
+package polluter
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/romanyx/jwalk"
+)
+
+// Table is a named list of rows seeded in
+// order. A row is a struct, a pointer to
+// a struct, a map with string keys or a
+// Record. Struct columns are named after
+// the db, bson or json tag, whichever
+// comes first, or after the field itself.
+type Table struct {
+	Name string
+	Rows []interface{}
+}
+
+// TablesWalker converts tables to the walker
+// database engines build commands from.
+func TablesWalker(tables []Table) (jwalk.ObjectWalker, error) {
+	doc := make(Record, 0, len(tables))
+
+	for _, t := range tables {
+		rows := make([]Record, 0, len(t.Rows))
+		for i, row := range t.Rows {
+			r, err := rowOf(reflect.ValueOf(row))
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s row %d", t.Name, i)
+			}
+			rows = append(rows, r)
+		}
+		doc = append(doc, Field{Name: t.Name, Value: rows})
+	}
+
+	return doc.Walker()
+}
+
+var (
+	recordType    = reflect.TypeOf(Record{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textType      = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func rowOf(v reflect.Value) (Record, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, errors.New("nil row")
+		}
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Type() == recordType:
+		return v.Interface().(Record), nil
+	case v.Kind() == reflect.Struct:
+		return structRecord(v, make(Record, 0))
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		return mapRecord(v), nil
+	default:
+		return nil, errors.Errorf("unsupported row type %s", v.Type())
+	}
+}
+
+func structRecord(v reflect.Value, r Record) (Record, error) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, opts, tagged := columnName(sf)
+		if name == "-" {
+			continue
+		}
+
+		// Like encoding/json, embedded structs are
+		// flattened even when their type is unexported.
+		fv := v.Field(i)
+		if sf.Anonymous && (!tagged || strings.Contains(opts, "inline")) {
+			ft := sf.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			for fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					break
+				}
+				fv = fv.Elem()
+			}
+			if ft.Kind() == reflect.Struct && fv.Kind() == reflect.Ptr {
+				// a nil embedded struct has no columns to add.
+				continue
+			}
+			if fv.Kind() == reflect.Struct {
+				var err error
+				if r, err = structRecord(fv, r); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		if strings.Contains(opts, "omitempty") && fv.IsZero() {
+			continue
+		}
+
+		r = r.Set(name, fieldValue(fv))
+	}
+
+	return r, nil
+}
+
+// columnName reads the column of a struct field
+// from its db, bson or json tag.
+func columnName(sf reflect.StructField) (name, opts string, tagged bool) {
+	for _, key := range []string{"db", "bson", "json"} {
+		tag, ok := sf.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+
+		name = tag
+		if i := strings.Index(tag, ","); i >= 0 {
+			name, opts = tag[:i], tag[i+1:]
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		return name, opts, true
+	}
+
+	return sf.Name, "", false
+}
+
+func mapRecord(v reflect.Value) Record {
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+
+	r := make(Record, 0, len(keys))
+	for _, k := range keys {
+		r = append(r, Field{Name: k, Value: fieldValue(v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key())))})
+	}
+	return r
+}
+
+// fieldValue turns nested structs and maps into
+// records so their fields keep the right names.
+// Anything that knows how to encode itself, like
+// time.Time, is left to encoding/json.
+func fieldValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type().Implements(marshalerType) || v.Type().Implements(textType) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return fieldValue(v.Elem())
+	case reflect.Struct:
+		if r, err := rowOf(v); err == nil {
+			return r
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() == reflect.String && !v.IsNil() {
+			return mapRecord(v)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = fieldValue(v.Index(i))
+		}
+		return items
+	}
+
+	return v.Interface()
+}
+
+// PolluteValue seeds a database with rows
+// given as Go values instead of a fixture.
+func (p *Polluter) PolluteValue(tables []Table) (err error) {
+	ctx, _, end := p.span(context.Background(), "polluter.PolluteValue")
+	defer end(&err)
+
+	obj, err := TablesWalker(tables)
+	if err != nil {
+		return errors.Wrap(err, "convert tables")
+	}
+
+	return p.pollute(ctx, obj)
+}
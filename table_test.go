@@ -0,0 +1,173 @@
+package polluter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/quen2404/polluter"
+	"github.com/romanyx/jwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+type Base struct {
+	ID int `db:"id"`
+}
+
+type user struct {
+	Base
+	Name      string    `db:"name" json:"full_name"`
+	Email     string    `json:"email,omitempty"`
+	RoleID    int       `bson:"role_id"`
+	CreatedAt time.Time `db:"created_at"`
+	Skipped   string    `db:"-"`
+	Tags      []string
+	secret    string
+}
+
+type admin struct {
+	*Base
+	Name string `db:"name"`
+}
+
+type audit struct {
+	By string `db:"by"`
+}
+
+type post struct {
+	audit
+	*Base
+	Title string `db:"title"`
+	level
+}
+
+type level int
+
+type buildFunc func(jwalk.ObjectWalker) (polluter.Commands, error)
+
+func (f buildFunc) Build(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+	return f(obj)
+}
+
+func (f buildFunc) Exec(polluter.Commands) error {
+	return nil
+}
+
+func TestTablesWalker(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		tables  []polluter.Table
+		expect  string
+		wantErr bool
+	}{
+		{
+			name: "structs",
+			tables: []polluter.Table{
+				{
+					Name: "users",
+					Rows: []interface{}{
+						user{Base: Base{ID: 1}, Name: "Roman", RoleID: 2, CreatedAt: created, Tags: []string{"a"}, secret: "x"},
+						&user{Base: Base{ID: 2}, Name: "Dmitry", Email: "d@example.com", CreatedAt: created},
+					},
+				},
+			},
+			expect: `{"users":[` +
+				`{"id":1,"name":"Roman","role_id":2,"created_at":"2020-01-02T03:04:05Z","Tags":["a"]},` +
+				`{"id":2,"name":"Dmitry","email":"d@example.com","role_id":0,"created_at":"2020-01-02T03:04:05Z","Tags":null}]}`,
+		},
+		{
+			name: "nil embedded pointer",
+			tables: []polluter.Table{
+				{
+					Name: "admins",
+					Rows: []interface{}{
+						admin{Base: &Base{ID: 1}, Name: "Roman"},
+						admin{Name: "Dmitry"},
+					},
+				},
+			},
+			expect: `{"admins":[{"id":1,"name":"Roman"},{"name":"Dmitry"}]}`,
+		},
+		{
+			name: "unexported embedded structs",
+			tables: []polluter.Table{
+				{
+					Name: "posts",
+					Rows: []interface{}{
+						post{audit: audit{By: "Roman"}, Base: &Base{ID: 1}, Title: "Hello", level: 2},
+					},
+				},
+			},
+			expect: `{"posts":[{"by":"Roman","id":1,"title":"Hello"}]}`,
+		},
+		{
+			name: "maps and records keep table order",
+			tables: []polluter.Table{
+				{
+					Name: "roles",
+					Rows: []interface{}{
+						map[string]interface{}{"name": "User", "id": 1},
+					},
+				},
+				{
+					Name: "users",
+					Rows: []interface{}{
+						polluter.Record{{Name: "name", Value: "Roman"}, {Name: "id", Value: 1}},
+					},
+				},
+			},
+			expect: `{"roles":[{"id":1,"name":"User"}],"users":[{"name":"Roman","id":1}]}`,
+		},
+		{
+			name: "unsupported row",
+			tables: []polluter.Table{
+				{Name: "users", Rows: []interface{}{1}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			obj, err := polluter.TablesWalker(tt.tables)
+
+			if tt.wantErr && err == nil {
+				assert.NotNil(t, err)
+				return
+			}
+
+			if !tt.wantErr {
+				if err != nil {
+					assert.Nil(t, err)
+					return
+				}
+
+				data, err := obj.MarshalJSON()
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expect, string(data))
+			}
+		})
+	}
+}
+
+func TestPolluterPolluteValue(t *testing.T) {
+	var got []string
+	engine := buildFunc(func(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+		return nil, obj.Walk(func(table string, _ interface{}) error {
+			got = append(got, table)
+			return nil
+		})
+	})
+
+	p := polluter.New(engine, nil)
+	err := p.PolluteValue([]polluter.Table{
+		{Name: "roles", Rows: []interface{}{map[string]interface{}{"id": 1}}},
+		{Name: "users", Rows: []interface{}{user{Name: "Roman"}}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"roles", "users"}, got)
+}
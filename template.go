@@ -0,0 +1,67 @@
+package polluter
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type templateConfig struct {
+	funcs template.FuncMap
+	data  interface{}
+}
+
+// Template option renders the input with
+// text/template before it is parsed, so
+// fixtures can use loops and helpers. Besides
+// the given funcs, templates can call:
+//
+//	seq N    // 1, 2, ..., N to range over
+//	now      // the current time.Time
+//
+// data is available as dot in the template.
+func Template(funcs template.FuncMap, data interface{}) Option {
+	return func(p *Polluter) {
+		p.template = &templateConfig{
+			funcs: funcs,
+			data:  data,
+		}
+	}
+}
+
+func (c *templateConfig) render(r io.Reader) (io.Reader, error) {
+	input, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "read input")
+	}
+
+	t, err := template.New("fixture").
+		Funcs(templateFuncs).
+		Funcs(c.funcs).
+		Parse(string(input))
+	if err != nil {
+		return nil, errors.Wrap(err, "parse template")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, c.data); err != nil {
+		return nil, errors.Wrap(err, "execute template")
+	}
+
+	return buf, nil
+}
+
+var templateFuncs = template.FuncMap{
+	"seq": func(n int) []int {
+		s := make([]int, n)
+		for i := range s {
+			s[i] = i + 1
+		}
+		return s
+	},
+	"now": time.Now,
+}
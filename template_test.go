@@ -0,0 +1,107 @@
+package polluter_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/romanyx/jwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		funcs   template.FuncMap
+		data    interface{}
+		input   string
+		expect  string
+		wantErr bool
+	}{
+		{
+			name: "loop with helpers and data",
+			funcs: template.FuncMap{
+				"email": func(i int) string {
+					return fmt.Sprintf("user%d@example.com", i)
+				},
+			},
+			data: map[string]interface{}{"Role": 2},
+			input: `users:
+{{- range seq 3 }}
+- id: {{ . }}
+  email: {{ email . }}
+  role_id: {{ $.Role }}
+{{- end }}
+`,
+			expect: `{"users":[` +
+				`{"id":1,"email":"user1@example.com","role_id":2},` +
+				`{"id":2,"email":"user2@example.com","role_id":2},` +
+				`{"id":3,"email":"user3@example.com","role_id":2}]}`,
+		},
+		{
+			name:    "invalid template",
+			input:   `users: {{ range }}`,
+			wantErr: true,
+		},
+		{
+			name:    "failing function",
+			funcs:   template.FuncMap{"fail": func() (string, error) { return "", fmt.Errorf("failed") }},
+			input:   `users: {{ fail }}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []byte
+			engine := buildFunc(func(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+				var err error
+				got, err = obj.MarshalJSON()
+				return nil, err
+			})
+
+			p := polluter.New(engine, yaml.YAMLParser(), polluter.Template(tt.funcs, tt.data))
+			err := p.Pollute(strings.NewReader(tt.input))
+
+			if tt.wantErr && err == nil {
+				assert.NotNil(t, err)
+				return
+			}
+
+			if !tt.wantErr {
+				assert.Nil(t, err)
+				if tt.expect != "" {
+					assert.Equal(t, tt.expect, string(got))
+				}
+			}
+		})
+	}
+}
+
+func TestTemplateNow(t *testing.T) {
+	var doc polluter.Record
+	engine := buildFunc(func(obj jwalk.ObjectWalker) (polluter.Commands, error) {
+		var err error
+		doc, err = polluter.RecordOf(obj)
+		return nil, err
+	})
+
+	p := polluter.New(engine, yaml.YAMLParser(), polluter.Template(nil, nil))
+	err := p.Pollute(strings.NewReader(`events: [{at: "{{ now.Format "2006-01-02T15:04:05Z07:00" }}"}]`))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	events, _ := doc.Get("events")
+	at, _ := events.([]polluter.Record)[0].Get("at")
+	ts, err := time.Parse(time.RFC3339, at.(string))
+	assert.Nil(t, err)
+	assert.WithinDuration(t, time.Now(), ts, time.Minute)
+}
@@ -0,0 +1,89 @@
+package polluter
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/quen2404/polluter"
+
+type tracerKey struct{}
+
+// Trace option creates OpenTelemetry spans with
+// tracers of tp: a span for each call to Pollute,
+// PolluteContext, PolluteValue and Create, with
+// children for parse, build, truncate and exec,
+// and a span for each command the engine runs
+// through RunCommand, tagged with the table and
+// rows of the command. Build spans count rows
+// and have an event per table.
+func Trace(tp trace.TracerProvider) Option {
+	return func(p *Polluter) {
+		p.tracer = tp.Tracer(tracerName)
+	}
+}
+
+// span starts a span if tracing is enabled. The
+// returned function ends it, recording *err.
+func (p *Polluter) span(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span, func(*error)) {
+	if p.tracer == nil {
+		return ctx, trace.SpanFromContext(context.Background()), func(*error) {}
+	}
+
+	ctx, span := p.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, span, func(err *error) {
+		endSpan(span, *err)
+	}
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// traceTables annotates a build
+// span with the rows of each table.
+func traceTables(span trace.Span, doc Record) {
+	if !span.IsRecording() {
+		return
+	}
+
+	total := 0
+	for _, f := range doc {
+		rows, ok := f.Value.([]Record)
+		if !ok {
+			continue
+		}
+		total += len(rows)
+		span.AddEvent("table", trace.WithAttributes(
+			attribute.String("polluter.table", f.Name),
+			attribute.Int("polluter.rows", len(rows)),
+		))
+	}
+	span.SetAttributes(attribute.Int("polluter.rows", total))
+}
+
+// runTraced runs a command in a span if
+// a tracer is found in ctx.
+func runTraced(ctx context.Context, cmd Command, exec func(Command) error) error {
+	tracer, ok := ctx.Value(tracerKey{}).(trace.Tracer)
+	if !ok {
+		return exec(cmd)
+	}
+
+	_, span := tracer.Start(ctx, "polluter.command", trace.WithAttributes(
+		attribute.String("db.statement", cmd.Q),
+		attribute.Int("polluter.args", len(cmd.Args)),
+		attribute.String("polluter.table", cmd.Table),
+		attribute.Int("polluter.rows", cmd.Rows),
+	))
+	err := exec(cmd)
+	endSpan(span, err)
+	return err
+}
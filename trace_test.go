@@ -0,0 +1,111 @@
+package polluter_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTrace(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		expect  []string
+		wantErr bool
+	}{
+		{
+			name:  "spans",
+			input: "users:\n- name: Roman\n- name: Dmitry\nroles:\n- name: User\n",
+			expect: []string{
+				"polluter.parse",
+				"polluter.build",
+				"polluter.command",
+				"polluter.command",
+				"polluter.command",
+				"polluter.exec",
+				"polluter.Pollute",
+			},
+		},
+		{
+			name:  "failed command",
+			input: "broken:\n- name: Roman\n",
+			expect: []string{
+				"polluter.parse",
+				"polluter.build",
+				"polluter.command",
+				"polluter.exec",
+				"polluter.Pollute",
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+			var executed []polluter.Command
+			p := polluter.New(hookedEngine{executed: &executed}, yaml.YAMLParser(), polluter.Trace(tp))
+			err := p.PolluteContext(context.Background(), strings.NewReader(tt.input))
+			assert.Equal(t, tt.wantErr, err != nil, "%v", err)
+
+			spans := exporter.GetSpans()
+			names := make([]string, 0, len(spans))
+			for _, s := range spans {
+				names = append(names, s.Name)
+			}
+			assert.Equal(t, tt.expect, names)
+
+			root := spans[len(spans)-1]
+			for _, s := range spans[:len(spans)-1] {
+				assert.Equal(t, root.SpanContext.TraceID(), s.SpanContext.TraceID())
+			}
+
+			if tt.wantErr {
+				assert.Equal(t, codes.Error, root.Status.Code)
+				assert.Equal(t, codes.Error, spans[2].Status.Code)
+				return
+			}
+
+			build := spans[1]
+			assert.Contains(t, build.Attributes, attribute.Int("polluter.rows", 3))
+			assert.Contains(t, build.Attributes, attribute.Int("polluter.commands", 3))
+			if assert.Len(t, build.Events, 2) {
+				assert.Contains(t, build.Events[0].Attributes, attribute.String("polluter.table", "users"))
+				assert.Contains(t, build.Events[0].Attributes, attribute.Int("polluter.rows", 2))
+			}
+			assert.Contains(t, spans[2].Attributes, attribute.String("db.statement", "users"))
+			assert.Contains(t, spans[2].Attributes, attribute.String("polluter.table", "users"))
+			assert.Contains(t, spans[2].Attributes, attribute.Int("polluter.rows", 1))
+		})
+	}
+}
+
+func TestTraceDisabled(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "test")
+
+	var executed []polluter.Command
+	p := polluter.New(hookedEngine{executed: &executed}, yaml.YAMLParser())
+	assert.Nil(t, p.PolluteContext(ctx, strings.NewReader("users:\n- name: Roman\n")))
+	span.End()
+
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.Empty(t, spans[0].Attributes)
+		assert.Empty(t, spans[0].Events)
+	}
+}
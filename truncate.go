@@ -0,0 +1,46 @@
+package polluter
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/romanyx/jwalk"
+)
+
+// Truncater is implemented by engines which can
+// empty tables, collections or keys, see Truncate.
+type Truncater interface {
+	Truncate(ctx context.Context, names ...string) error
+}
+
+// Truncate option empties the tables, collections
+// and keys of a fixture before Pollute seeds it.
+// They are emptied in the reverse order of the
+// fixture so that referencing tables go first.
+// Create and PolluteValue keep existing rows.
+// The engine must implement Truncater.
+func Truncate() Option {
+	return func(p *Polluter) {
+		p.truncate = true
+	}
+}
+
+func (p *Polluter) truncateTables(ctx context.Context, obj jwalk.ObjectWalker) (err error) {
+	ctx, _, end := p.span(ctx, "polluter.truncate")
+	defer end(&err)
+
+	t, ok := p.DbEngine.(Truncater)
+	if !ok {
+		return errors.New("engine does not support truncate")
+	}
+
+	names := make([]string, 0)
+	if err := obj.Walk(func(name string, _ interface{}) error {
+		names = append([]string{name}, names...)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return t.Truncate(ctx, names...)
+}
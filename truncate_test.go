@@ -0,0 +1,40 @@
+package polluter_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+type truncaterEngine struct {
+	fakeEngine
+	truncated *[]string
+}
+
+func (e truncaterEngine) Truncate(_ context.Context, names ...string) error {
+	*e.truncated = append(*e.truncated, names...)
+	return nil
+}
+
+func TestTruncate(t *testing.T) {
+	input := "roles:\n- id: 1\nusers:\n- id: 1\nsession: abc\n"
+
+	var truncated []string
+	p := polluter.New(truncaterEngine{truncated: &truncated}, yaml.YAMLParser(), polluter.Truncate())
+	assert.Nil(t, p.Pollute(strings.NewReader(input)))
+	assert.Equal(t, []string{"session", "users", "roles"}, truncated)
+
+	p = polluter.New(fakeEngine{}, yaml.YAMLParser(), polluter.Truncate())
+	assert.NotNil(t, p.Pollute(strings.NewReader(input)))
+}
+
+func TestTruncateCreate(t *testing.T) {
+	var truncated []string
+	p := polluter.New(truncaterEngine{truncated: &truncated}, yaml.YAMLParser(), polluter.Truncate())
+	assert.Nil(t, p.Create(context.Background(), "users", map[string]interface{}{"id": 1}))
+	assert.Empty(t, truncated)
+}
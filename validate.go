@@ -0,0 +1,171 @@
+package polluter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type (
+	// Column describes a column of a table.
+	Column struct {
+		Name string
+		// Required columns are NOT NULL and
+		// have no default, nor are generated.
+		Required bool
+	}
+
+	// Describer is implemented by engines which
+	// can read the schema of the database, see
+	// Validate.
+	Describer interface {
+		// Describe returns the columns of
+		// the tables, in order, by table.
+		Describe(context.Context) (map[string][]Column, error)
+	}
+
+	// Problem is a mismatch between a fixture
+	// and the schema of the database.
+	Problem struct {
+		// Table, Row and Column locate the problem
+		// in the fixture. Row counts the rows of
+		// the table from 0, once includes are
+		// merged, and is -1 when the problem is
+		// about the table. Column is empty when
+		// the problem is about the row or table.
+		Table   string
+		Row     int
+		Column  string
+		Message string
+	}
+
+	// ValidationError is returned by Validate
+	// when the fixture does not fit the schema.
+	ValidationError struct {
+		Problems []Problem
+	}
+)
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "fixture does not fit the schema in %d place(s):", len(e.Problems))
+	for _, p := range e.Problems {
+		b.WriteString("\n")
+		b.WriteString(p.String())
+	}
+	return b.String()
+}
+
+func (p Problem) String() string {
+	pos := p.Table
+	if p.Row >= 0 {
+		pos = fmt.Sprintf("%s[%d]", pos, p.Row)
+	}
+	if p.Column != "" {
+		pos = pos + "." + p.Column
+	}
+	return pos + ": " + p.Message
+}
+
+// Validate parses the fixture from the reader, like
+// Pollute does, and checks it against the schema
+// of the database without seeding it: its tables
+// and columns must exist and required columns be
+// given. All the problems are reported by a
+// *ValidationError. The engine must implement
+// Describer.
+func (p *Polluter) Validate(ctx context.Context, r io.Reader) error {
+	describer, ok := p.DbEngine.(Describer)
+	if !ok {
+		return errors.New("engine does not support validate")
+	}
+
+	obj, err := p.parse(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	doc, err := p.records(obj)
+	if err != nil {
+		return err
+	}
+
+	if doc, err = p.generators.generate(doc); err != nil {
+		return errors.Wrap(err, "generate failed")
+	}
+
+	tables, err := describer.Describe(ctx)
+	if err != nil {
+		return errors.Wrap(err, "describe failed")
+	}
+
+	if problems := validate(doc, tables); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+func validate(doc Record, tables map[string][]Column) []Problem {
+	problems := make([]Problem, 0)
+
+	for _, f := range doc {
+		rows, ok := f.Value.([]Record)
+		if !ok {
+			continue
+		}
+
+		columns, ok := tables[f.Name]
+		if !ok {
+			problems = append(problems, Problem{Table: f.Name, Row: -1, Message: "unknown table"})
+			continue
+		}
+
+		for i, row := range rows {
+			problems = append(problems, validateRow(f.Name, i, row, columns)...)
+		}
+	}
+
+	return problems
+}
+
+func validateRow(table string, i int, row Record, columns []Column) []Problem {
+	problems := make([]Problem, 0)
+
+	for _, f := range row {
+		if !hasColumn(columns, f.Name) {
+			problems = append(problems, Problem{Table: table, Row: i, Column: f.Name, Message: "unknown column"})
+		}
+	}
+
+	for _, c := range columns {
+		if !c.Required {
+			continue
+		}
+
+		v, ok := row.Get(c.Name)
+		expr, raw := RawSQL(v)
+		switch {
+		case !ok:
+			problems = append(problems, Problem{Table: table, Row: i, Column: c.Name, Message: "missing required column"})
+		case v == nil:
+			problems = append(problems, Problem{Table: table, Row: i, Column: c.Name, Message: "null in required column"})
+		case raw && expr == "DEFAULT":
+			problems = append(problems, Problem{Table: table, Row: i, Column: c.Name, Message: "required column has no default"})
+		}
+	}
+
+	return problems
+}
+
+func hasColumn(columns []Column, name string) bool {
+	for _, c := range columns {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package polluter_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/stretchr/testify/assert"
+)
+
+type describerEngine struct {
+	fakeEngine
+	tables map[string][]polluter.Column
+}
+
+func (e describerEngine) Describe(context.Context) (map[string][]polluter.Column, error) {
+	return e.tables, nil
+}
+
+func TestPolluterValidate(t *testing.T) {
+	tables := map[string][]polluter.Column{
+		"users": {
+			{Name: "id"},
+			{Name: "name", Required: true},
+			{Name: "created_at"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		input  string
+		expect []polluter.Problem
+	}{
+		{
+			name:  "valid",
+			input: "users:\n- id: 1\n  name: Roman\n  created_at: !sql now()\ncount: 1\n",
+		},
+		{
+			name: "problems",
+			input: `users:
+- id: 1
+  nmae: Roman
+- name: ~
+- name: !default
+roles:
+- id: 1
+`,
+			expect: []polluter.Problem{
+				{Table: "users", Row: 0, Column: "nmae", Message: "unknown column"},
+				{Table: "users", Row: 0, Column: "name", Message: "missing required column"},
+				{Table: "users", Row: 1, Column: "name", Message: "null in required column"},
+				{Table: "users", Row: 2, Column: "name", Message: "required column has no default"},
+				{Table: "roles", Row: -1, Message: "unknown table"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := polluter.New(describerEngine{tables: tables}, yaml.YAMLParser())
+			err := p.Validate(context.Background(), strings.NewReader(tt.input))
+			if tt.expect == nil {
+				assert.Nil(t, err)
+				return
+			}
+
+			verr, ok := err.(*polluter.ValidationError)
+			if !assert.True(t, ok, "%v", err) {
+				return
+			}
+			assert.Equal(t, tt.expect, verr.Problems)
+		})
+	}
+}
+
+func TestProblem_String(t *testing.T) {
+	assert.Equal(t, "users[1].nmae: unknown column", polluter.Problem{Table: "users", Row: 1, Column: "nmae", Message: "unknown column"}.String())
+	assert.Equal(t, "roles: unknown table", polluter.Problem{Table: "roles", Row: -1, Message: "unknown table"}.String())
+}
+
+func TestPolluterValidate_unsupported(t *testing.T) {
+	p := polluter.New(fakeEngine{}, yaml.YAMLParser())
+	assert.NotNil(t, p.Validate(context.Background(), strings.NewReader("users: []")))
+}
@@ -0,0 +1,481 @@
+package polluter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// keyColumns identify a row when it differs
+// from the expected one, in that order.
+var keyColumns = []string{"id", "_id"}
+
+type (
+	// DiffKind tells how the database
+	// differs from a fixture.
+	DiffKind int
+
+	// Diff is a single difference between
+	// a fixture and the database.
+	Diff struct {
+		Kind DiffKind
+		// Table is the table, collection
+		// or key the difference is about.
+		Table    string
+		Expected interface{}
+		Actual   interface{}
+		// Fields lists the differing fields
+		// of a Changed row.
+		Fields []string
+	}
+
+	// VerifyError is returned by Verify
+	// when the database differs from
+	// the fixture.
+	VerifyError struct {
+		Diffs []Diff
+	}
+)
+
+const (
+	// Missing rows or keys are in the
+	// fixture but not in the database.
+	Missing DiffKind = iota
+	// Extra rows are in the database
+	// but not in the fixture.
+	Extra
+	// Changed rows or keys are in both
+	// but with different values.
+	Changed
+)
+
+func (e *VerifyError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "database differs from fixture in %d place(s):", len(e.Diffs))
+	for _, d := range e.Diffs {
+		b.WriteString("\n")
+		b.WriteString(d.String())
+	}
+	return b.String()
+}
+
+func (d Diff) String() string {
+	switch d.Kind {
+	case Missing:
+		return fmt.Sprintf("- %s: %s", d.Table, format(d.Expected))
+	case Extra:
+		return fmt.Sprintf("+ %s: %s", d.Table, format(d.Actual))
+	default:
+		if len(d.Fields) == 0 {
+			return fmt.Sprintf("~ %s: expected %s, got %s", d.Table, format(d.Expected), format(d.Actual))
+		}
+
+		expected, _ := d.Expected.(Record)
+		actual, _ := d.Actual.(Record)
+		parts := make([]string, 0, len(d.Fields))
+		for _, f := range d.Fields {
+			e, _ := expected.Get(f)
+			a, _ := actual.Get(f)
+			parts = append(parts, fmt.Sprintf("%s: expected %s, got %s", f, format(e), format(a)))
+		}
+		return fmt.Sprintf("~ %s: %s\n    %s", d.Table, format(expected), strings.Join(parts, "\n    "))
+	}
+}
+
+func format(v interface{}) string {
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// Verify parses the fixture from the reader, like
+// Pollute does, and checks that the database holds
+// exactly the rows of its tables and the values
+// of its keys. Rows are compared on the columns
+// of the fixture only. Differences are reported
+// by a *VerifyError. The engine must implement
+// Checker.
+func (p *Polluter) Verify(ctx context.Context, r io.Reader) error {
+	checker, ok := p.DbEngine.(Checker)
+	if !ok {
+		return errors.New("engine does not support verify")
+	}
+
+	obj, err := p.parse(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	expected, err := p.records(obj)
+	if err != nil {
+		return err
+	}
+
+	if obj, err = expected.Walker(); err != nil {
+		return errors.Wrap(err, "convert records")
+	}
+
+	actual, err := checker.Fetch(ctx, obj)
+	if err != nil {
+		return errors.Wrap(err, "fetch failed")
+	}
+
+	if diffs := Compare(expected, actual); len(diffs) > 0 {
+		return &VerifyError{Diffs: diffs}
+	}
+
+	return nil
+}
+
+// Compare returns the differences between
+// expected fixture records and actual
+// records fetched by a Checker.
+func Compare(expected, actual Record) []Diff {
+	diffs := make([]Diff, 0)
+
+	for _, f := range expected {
+		a, ok := actual.Get(f.Name)
+		if rows, isRows := f.Value.([]Record); isRows {
+			diffs = append(diffs, compareRows(f.Name, rows, asRows(a))...)
+			continue
+		}
+
+		switch {
+		case !ok:
+			diffs = append(diffs, Diff{Kind: Missing, Table: f.Name, Expected: f.Value})
+		case !equal(f.Value, a):
+			diffs = append(diffs, Diff{Kind: Changed, Table: f.Name, Expected: f.Value, Actual: a})
+		}
+	}
+
+	return diffs
+}
+
+func compareRows(table string, expected, actual []Record) []Diff {
+	columns := make([]string, 0)
+	for _, r := range expected {
+		for _, f := range r {
+			if !containsString(columns, f.Name) {
+				columns = append(columns, f.Name)
+			}
+		}
+	}
+
+	left := make([]Record, 0, len(actual))
+	for _, r := range actual {
+		left = append(left, project(r, columns))
+	}
+
+	missing := make([]Record, 0)
+	for _, e := range expected {
+		if i := findRow(left, func(a Record) bool { return len(changedFields(e, a)) == 0 }); i >= 0 {
+			left = append(left[:i], left[i+1:]...)
+			continue
+		}
+		missing = append(missing, e)
+	}
+
+	diffs := make([]Diff, 0)
+	for _, e := range missing {
+		key, value, ok := rowKey(e)
+		i := -1
+		if ok {
+			i = findRow(left, func(a Record) bool {
+				v, found := a.Get(key)
+				return found && equal(value, v)
+			})
+		}
+
+		if i < 0 {
+			diffs = append(diffs, Diff{Kind: Missing, Table: table, Expected: e})
+			continue
+		}
+
+		diffs = append(diffs, Diff{
+			Kind:     Changed,
+			Table:    table,
+			Expected: e,
+			Actual:   left[i],
+			Fields:   changedFields(e, left[i]),
+		})
+		left = append(left[:i], left[i+1:]...)
+	}
+
+	for _, a := range left {
+		diffs = append(diffs, Diff{Kind: Extra, Table: table, Actual: a})
+	}
+
+	return diffs
+}
+
+// asRows converts rows decoded from
+// JSON to records with sorted fields.
+func asRows(v interface{}) []Record {
+	switch v := v.(type) {
+	case []Record:
+		return v
+	case []interface{}:
+		rows := make([]Record, 0, len(v))
+		for _, item := range v {
+			switch i := item.(type) {
+			case Record:
+				rows = append(rows, i)
+			case map[string]interface{}:
+				rows = append(rows, mapRecord(reflect.ValueOf(i)))
+			}
+		}
+		return rows
+	}
+	return nil
+}
+
+func rowKey(r Record) (string, interface{}, bool) {
+	for _, key := range keyColumns {
+		if v, ok := r.Get(key); ok {
+			return key, v, true
+		}
+	}
+	return "", nil, false
+}
+
+func findRow(rows []Record, match func(Record) bool) int {
+	for i, r := range rows {
+		if match(r) {
+			return i
+		}
+	}
+	return -1
+}
+
+func project(r Record, columns []string) Record {
+	res := make(Record, 0, len(columns))
+	for _, c := range columns {
+		if v, ok := r.Get(c); ok {
+			res = append(res, Field{Name: c, Value: v})
+		}
+	}
+	return res
+}
+
+func changedFields(expected, actual Record) []string {
+	fields := make([]string, 0)
+	for _, f := range expected {
+		v, ok := actual.Get(f.Name)
+		if !ok || !equal(f.Value, v) {
+			fields = append(fields, f.Name)
+		}
+	}
+	return fields
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// equal compares a fixture value with a value
+// read from a database, forgiving the type
+// differences drivers introduce: numbers of any
+// type, []byte for strings, booleans stored as
+// numbers and times read back as time.Time.
+// Raw SQL values match anything.
+func equal(expected, actual interface{}) bool {
+	if _, ok := RawSQL(expected); ok {
+		// The database computed the value.
+		return true
+	}
+	e, a := normalize(expected), normalize(actual)
+
+	switch ev := e.(type) {
+	case nil:
+		return a == nil
+	case map[string]interface{}:
+		av, ok := a.(map[string]interface{})
+		if !ok || len(av) != len(ev) {
+			return false
+		}
+		for k, v := range ev {
+			if !equal(v, av[k]) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		av, ok := a.([]interface{})
+		if !ok || len(av) != len(ev) {
+			return false
+		}
+		for i := range ev {
+			if !equal(ev[i], av[i]) {
+				return false
+			}
+		}
+		return true
+	case bool:
+		switch av := a.(type) {
+		case bool:
+			return ev == av
+		case *big.Float:
+			return (av.Sign() != 0) == ev
+		case string:
+			b, err := strconv.ParseBool(av)
+			return err == nil && b == ev
+		}
+		return false
+	case *big.Float:
+		switch av := a.(type) {
+		case *big.Float:
+			return ev.Cmp(av) == 0
+		case bool:
+			return (ev.Sign() != 0) == av
+		case string:
+			f, ok := parseNumber(av)
+			return ok && ev.Cmp(f) == 0
+		}
+		return false
+	case string:
+		switch av := a.(type) {
+		case string:
+			return ev == av
+		case time.Time:
+			return equalTime(ev, av)
+		case *big.Float:
+			f, ok := parseNumber(ev)
+			return ok && f.Cmp(av) == 0
+		}
+		return false
+	case time.Time:
+		switch av := a.(type) {
+		case time.Time:
+			return ev.Equal(av)
+		case string:
+			return equalTime(av, ev)
+		}
+		return false
+	}
+
+	return reflect.DeepEqual(e, a)
+}
+
+// parseNumber parses s as a number. Integers keep
+// all their digits, other numbers are rounded to
+// the precision of the float64 read by drivers.
+func parseNumber(s string) (*big.Float, bool) {
+	if i, ok := new(big.Int).SetString(s, 10); ok {
+		return new(big.Float).SetInt(i), true
+	}
+	f, _, err := big.ParseFloat(s, 10, 53, big.ToNearestEven)
+	return f, err == nil
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+	"15:04:05.999999999",
+}
+
+func equalTime(s string, t time.Time) bool {
+	for _, layout := range timeLayouts {
+		if parsed, err := time.ParseInLocation(layout, s, t.Location()); err == nil {
+			return parsed.Equal(t) || (layout == "15:04:05.999999999" && parsed.Format(layout) == t.Format(layout))
+		}
+	}
+	return false
+}
+
+// normalize converts values to a few comparable
+// types: nil, bool, string, time.Time, *big.Float,
+// map[string]interface{} and []interface{}.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil, bool, string, time.Time:
+		return v
+	case []byte:
+		return string(v)
+	case json.Number:
+		f, ok := parseNumber(string(v))
+		if !ok {
+			return string(v)
+		}
+		return f
+	case Record:
+		m := make(map[string]interface{}, len(v))
+		for _, f := range v {
+			m[f.Name] = normalize(f.Value)
+		}
+		return m
+	case []Record:
+		list := make([]interface{}, len(v))
+		for i, r := range v {
+			list[i] = normalize(r)
+		}
+		return list
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = normalize(item)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = normalize(item)
+		}
+		return list
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return new(big.Float).SetInt64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Float).SetUint64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return big.NewFloat(rv.Float())
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil
+		}
+		return normalize(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array:
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = normalize(rv.Index(i).Interface())
+		}
+		return list
+	}
+
+	if m, ok := v.(json.Marshaler); ok {
+		if data, err := m.MarshalJSON(); err == nil {
+			d := json.NewDecoder(bytes.NewReader(data))
+			d.UseNumber()
+			var i interface{}
+			if err := d.Decode(&i); err == nil {
+				return normalize(i)
+			}
+		}
+	}
+
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,193 @@
+package polluter_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/quen2404/polluter"
+	"github.com/quen2404/polluter/database/mongo"
+	"github.com/quen2404/polluter/database/mysql"
+	"github.com/quen2404/polluter/database/postgres"
+	"github.com/quen2404/polluter/database/redis"
+	"github.com/quen2404/polluter/internal/db_test"
+	"github.com/quen2404/polluter/parser/yaml"
+	"github.com/romanyx/jwalk"
+	"github.com/stretchr/testify/assert"
+)
+
+type checkerEngine struct {
+	fakeEngine
+	state polluter.Record
+}
+
+func (e checkerEngine) Fetch(context.Context, jwalk.ObjectWalker) (polluter.Record, error) {
+	return e.state, nil
+}
+
+const verifyInput = `users:
+- id: 1
+  name: Roman
+  active: true
+  created_at: "2020-01-02 03:04:05"
+- id: 2
+  name: Dmitry
+- name: Anna
+count: 1
+settings:
+  theme: dark
+`
+
+func TestPolluterVerify(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		state  polluter.Record
+		expect []polluter.Diff
+	}{
+		{
+			name: "matching state with driver types",
+			state: polluter.Record{
+				{Name: "users", Value: []polluter.Record{
+					{{Name: "id", Value: int64(2)}, {Name: "name", Value: []byte("Dmitry")}, {Name: "active", Value: nil}, {Name: "created_at", Value: nil}},
+					{{Name: "id", Value: int64(3)}, {Name: "name", Value: "Anna"}, {Name: "active", Value: int64(0)}, {Name: "created_at", Value: nil}},
+					{{Name: "id", Value: int64(1)}, {Name: "name", Value: "Roman"}, {Name: "active", Value: "1"}, {Name: "created_at", Value: created}},
+				}},
+				{Name: "count", Value: float64(1)},
+				{Name: "settings", Value: map[string]interface{}{"theme": "dark"}},
+			},
+			expect: nil,
+		},
+		{
+			name: "missing, extra and changed",
+			state: polluter.Record{
+				{Name: "users", Value: []polluter.Record{
+					{{Name: "id", Value: int64(1)}, {Name: "name", Value: "Roma"}, {Name: "active", Value: true}, {Name: "created_at", Value: created}},
+					{{Name: "id", Value: int64(4)}, {Name: "name", Value: "Anna"}},
+					{{Name: "id", Value: int64(5)}, {Name: "name", Value: "Ivan"}},
+				}},
+				{Name: "count", Value: "2"},
+			},
+			expect: []polluter.Diff{
+				{Kind: polluter.Changed, Table: "users", Fields: []string{"name"}},
+				{Kind: polluter.Missing, Table: "users"},
+				{Kind: polluter.Extra, Table: "users"},
+				{Kind: polluter.Changed, Table: "count"},
+				{Kind: polluter.Missing, Table: "settings"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := polluter.New(checkerEngine{state: tt.state}, yaml.YAMLParser())
+			err := p.Verify(context.Background(), strings.NewReader(verifyInput))
+
+			if tt.expect == nil {
+				assert.Nil(t, err)
+				return
+			}
+
+			verr, ok := err.(*polluter.VerifyError)
+			if !ok {
+				assert.IsType(t, &polluter.VerifyError{}, err)
+				return
+			}
+
+			if assert.Len(t, verr.Diffs, len(tt.expect)) {
+				for i, d := range tt.expect {
+					assert.Equal(t, d.Kind, verr.Diffs[i].Kind)
+					assert.Equal(t, d.Table, verr.Diffs[i].Table)
+					if d.Fields != nil {
+						assert.Equal(t, d.Fields, verr.Diffs[i].Fields)
+					}
+				}
+			}
+			assert.Contains(t, err.Error(), `name: expected "Roman", got "Roma"`)
+			assert.Contains(t, err.Error(), `+ users: {"id":5,"name":"Ivan"}`)
+		})
+	}
+}
+
+func TestPolluterVerifyEngines(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping test in short mode")
+	}
+
+	tests := []struct {
+		name   string
+		option func(t *testing.T) (polluter.DbEngine, func() error)
+		input  string
+	}{
+		{
+			name: "mysql",
+			option: func(t *testing.T) (polluter.DbEngine, func() error) {
+				db, teardown := db_test.PrepareMySQLDB(t)
+				return mysql.MySQLEngine(db), teardown
+			},
+			input: input,
+		},
+		{
+			name: "postgres",
+			option: func(t *testing.T) (polluter.DbEngine, func() error) {
+				db, teardown := db_test.PreparePostgresDB(t)
+				return postgres.PostgresEngine(db), teardown
+			},
+			input: pgInput,
+		},
+		{
+			name: "redis",
+			option: func(t *testing.T) (polluter.DbEngine, func() error) {
+				db, teardown := db_test.PrepareRedisDB(t, 12)
+				return redis.RedisEngine(db), teardown
+			},
+			input: input,
+		},
+		{
+			name: "mongo",
+			option: func(t *testing.T) (polluter.DbEngine, func() error) {
+				db, teardown := db_test.PrepareMongoDB(t)
+				return mongo.MongoEngine(db), teardown
+			},
+			input: input,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			engine, teardown := tt.option(t)
+			defer func() {
+				_ = teardown()
+			}()
+
+			p := polluter.New(engine, yaml.YAMLParser())
+			assert.Nil(t, p.Pollute(strings.NewReader(tt.input)))
+			assert.Nil(t, p.Verify(context.Background(), strings.NewReader(tt.input)))
+		})
+	}
+}
+
+func TestPolluterVerifyFloats(t *testing.T) {
+	input := "prices:\n- amount: 1.1\n  tax: 0.2\n"
+	state := polluter.Record{
+		{Name: "prices", Value: []polluter.Record{
+			{{Name: "amount", Value: 1.1}, {Name: "tax", Value: "0.2"}},
+		}},
+	}
+
+	p := polluter.New(checkerEngine{state: state}, yaml.YAMLParser())
+	assert.Nil(t, p.Verify(context.Background(), strings.NewReader(input)))
+}
+
+func TestPolluterVerifyUnsupported(t *testing.T) {
+	p := polluter.New(fakeEngine{}, yaml.YAMLParser())
+	assert.NotNil(t, p.Verify(context.Background(), strings.NewReader(verifyInput)))
+}